@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// newBenchCmd creates the `bench` command, which times comparing and rendering
+// synthetic snapshots of several sizes without a cluster
+func newBenchCmd() *cobra.Command {
+	var (
+		sizes       []int
+		changeRatio float64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Time comparing and rendering synthetic snapshots of several sizes",
+		Long: "Generates pairs of synthetic snapshots and times each step after capturing:\n" +
+			"decoding a saved snapshot, comparing, and rendering the table and JSON output.\n" +
+			"No cluster is needed, so the numbers are a baseline to optimize against and to\n" +
+			"include when reporting slowness. Time real captures with 'run --bench'.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if changeRatio <= 0 || changeRatio > 0.5 {
+				fmt.Fprintf(os.Stderr, "Error: invalid --change-ratio %g: must be greater than 0 and at most 0.5\n", changeRatio)
+				os.Exit(1)
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(tw, "RESOURCES\tCHANGES\tDECODE\tCOMPARE\tTABLE\tJSON")
+			for _, size := range sizes {
+				if size <= 0 {
+					fmt.Fprintf(os.Stderr, "Error: invalid size %d: must be greater than 0\n", size)
+					os.Exit(1)
+				}
+				if err := benchSize(tw, size, changeRatio); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			tw.Flush()
+		},
+	}
+
+	cmd.Flags().IntSliceVar(&sizes, "sizes", []int{1000, 10000, 50000}, "Numbers of resources in the baseline snapshots to benchmark")
+	cmd.Flags().Float64Var(&changeRatio, "change-ratio", 0.1, "Share of resources modified, and again added or removed, between the snapshots")
+
+	return cmd
+}
+
+// benchSize times one synthetic snapshot pair and writes a row to w
+func benchSize(w io.Writer, size int, changeRatio float64) error {
+	baseline, current := snapshot.SyntheticPair(size, changeRatio)
+
+	// Encoded as WriteFile does
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	decodeDuration, err := timed(func() error {
+		_, err := snapshot.Decode(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var result *diff.DiffResult
+	compareDuration, _ := timed(func() error {
+		result = diff.Compare(baseline, current)
+		return nil
+	})
+	tableDuration, _ := timed(func() error {
+		diff.OutputTable(result, io.Discard)
+		return nil
+	})
+	jsonDuration, _ := timed(func() error {
+		diff.OutputJSON(result, io.Discard)
+		return nil
+	})
+
+	changes := len(result.Added) + len(result.Removed) + len(result.Modified)
+	fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\n", size, changes,
+		round(decodeDuration), round(compareDuration), round(tableDuration), round(jsonDuration))
+	return nil
+}
+
+// timed runs fn and returns how long it took
+func timed(fn func() error) (time.Duration, error) {
+	start := time.Now()
+	err := fn()
+	return time.Since(start), err
+}
+
+// round rounds a duration for display
+func round(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// newCompareCmd creates the `compare` command, which diffs two saved snapshot files
+func newCompareCmd() *cobra.Command {
+	var (
+		output   outputFlags
+		compare  compareFlags
+		original string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "compare <baseline.json> <current.json>",
+		Short: "Compare two saved snapshot files",
+		Long: "Loads two snapshots saved by k8s-rdiff and prints the diff between them.\n\n" +
+			"Warns on stderr when the snapshots were captured with different filters or\n" +
+			"namespaces. Exits with 0 when no changes were detected, 2 when changes were\n" +
+			"detected and 3 on error.\n\n" +
+			"With --original, each change is also compared against an older snapshot and\n" +
+			"marked Reverted if it put the resource back the way it was then (e.g. to verify\n" +
+			"a rollback, or spot a fix that reintroduced an old state), or Diverged otherwise.",
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := output.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			if err := compare.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+
+			baseline, err := snapshot.LoadFromFile(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading baseline: %v\n", err)
+				os.Exit(exitError)
+			}
+
+			current, err := snapshot.LoadFromFile(args[1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading current snapshot: %v\n", err)
+				os.Exit(exitError)
+			}
+
+			if clusters := snapshot.DescribeClusters(baseline, current); clusters != "" {
+				fmt.Fprintf(os.Stderr, "Cluster: %s\n", clusters)
+			}
+			printNotes(os.Stderr, baseline, current)
+
+			compareOpts := compare.compareOptions()
+			if original != "" {
+				os.Exit(compareThree(original, baseline, current, compareOpts, output))
+			}
+			compareOpts.CollectUnchanged = output.showUnchanged
+			result := diff.CompareWithOptions(baseline, current, compareOpts)
+			os.Exit(printDiff(result.ExcludeNamespaces(output.hideNamespaces), output))
+		},
+	}
+
+	addCompareFlags(cmd, &compare)
+	addOutputFlags(cmd, &output)
+	cmd.Flags().StringVar(&original, "original", "", "Older snapshot to classify each change against: Reverted if it restored the original state, Diverged otherwise (table or json output)")
+
+	return cmd
+}
+
+// compareThree prints the changes from baseline to current classified against
+// the original snapshot at path, returning the matching exit code
+func compareThree(path string, baseline, current *snapshot.Snapshot, opts diff.CompareOptions, output outputFlags) int {
+	format := strings.ToLower(output.format)
+	if format != "table" && format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: --original only supports the table and json output formats, not %q\n", output.format)
+		return exitError
+	}
+
+	original, err := snapshot.LoadFromFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading original snapshot: %v\n", err)
+		return exitError
+	}
+
+	// Narrow the changes down like the two-way output before classifying them
+	changes := diff.CompareWithOptions(baseline, current, opts).ExcludeNamespaces(output.hideNamespaces)
+	if output.viewNamespace != "" {
+		changes = changes.Filter(diff.FilterOptions{Namespaces: []string{output.viewNamespace}})
+	}
+	changes = changes.Filter(output.filterOptions()).
+		FilterBySeverity(output.severity()).
+		LimitPerKind(output.maxPerKind)
+
+	result := diff.ClassifyChanges(original, baseline, changes, opts)
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	for _, kind := range changes.TruncatedKinds() {
+		fmt.Fprintf(os.Stderr, "Note: left out %d %s changes beyond --max-per-kind %d\n", changes.Truncated[kind], kind, output.maxPerKind)
+	}
+
+	if format == "json" {
+		diff.OutputThreeWayJSON(result, os.Stdout)
+	} else if len(result.Changes) == 0 {
+		fmt.Println("No differences detected")
+	} else {
+		diff.OutputThreeWayTable(result, os.Stdout)
+	}
+
+	if len(result.Changes) == 0 {
+		return exitNoChanges
+	}
+	return exitChanges
+}
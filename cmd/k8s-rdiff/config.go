@@ -0,0 +1,170 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+	"gopkg.in/yaml.v2"
+)
+
+// localConfigFileName is the config file looked up in the working directory
+const localConfigFileName = ".k8s-rdiff.yaml"
+
+// configFilePaths returns the config files to look for, in order of precedence:
+// the working directory, then $XDG_CONFIG_HOME/k8s-rdiff/config.yaml
+// (~/.config/k8s-rdiff/config.yaml by default)
+func configFilePaths() []string {
+	paths := []string{localConfigFileName}
+	if dir := userConfigDir(); dir != "" {
+		paths = append(paths, filepath.Join(dir, "config.yaml"))
+	}
+	return paths
+}
+
+// userConfigDir returns $XDG_CONFIG_HOME/k8s-rdiff (~/.config/k8s-rdiff by
+// default), or "" if there's no home directory to put it in
+func userConfigDir() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome == "" {
+		return ""
+	}
+	return filepath.Join(configHome, "k8s-rdiff")
+}
+
+// secretDigestKeyFileName is the file in the user config directory holding the
+// key Secret values are digested with
+const secretDigestKeyFileName = "secret-digest.key"
+
+// loadSecretDigestKey returns the key captures digest Secret values with,
+// generating it on first use. Keeping it in the user config directory lets
+// digests of the same value match across captures and runs, while nobody
+// without the key can test guesses against the digests in a snapshot.
+func loadSecretDigestKey() ([]byte, error) {
+	dir := userConfigDir()
+	if dir == "" {
+		return nil, fmt.Errorf("no home directory for %s", secretDigestKeyFileName)
+	}
+	path := filepath.Join(dir, secretDigestKeyFileName)
+
+	key, err := ioutil.ReadFile(path)
+	if err == nil && len(key) == snapshot.SecretDigestKeySize {
+		return key, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	key = make([]byte, snapshot.SecretDigestKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate %s: %v", path, err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+	if err := ioutil.WriteFile(path, key, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return key, nil
+}
+
+// loadConfig reads the first config file found. Its keys are long flag names and
+// its values the flag values, lists for repeatable flags. No file yields no settings.
+func loadConfig() (map[string]interface{}, string, error) {
+	for _, path := range configFilePaths() {
+		data, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, path, fmt.Errorf("failed to read config file %s: %v", path, err)
+		}
+
+		settings := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &settings); err != nil {
+			return nil, path, fmt.Errorf("invalid config file %s: %v", path, err)
+		}
+		return settings, path, nil
+	}
+
+	return nil, "", nil
+}
+
+// overriddenSettings maps flags to the flag whose setting in the config file
+// they override when given on the command line, because the two can't be
+// combined: --all-namespaces replaces a configured namespace and vice versa
+var overriddenSettings = map[string]string{
+	"namespace":      "all-namespaces",
+	"all-namespaces": "namespace",
+}
+
+// configuredFlags holds the names of the flags applyConfig set from the config
+// file, which pflag reports as changed just like those on the command line
+var configuredFlags = map[string]bool{}
+
+// setOnCommandLine reports whether the named flag of cmd was given on the
+// command line rather than taken from the config file or left at its default
+func setOnCommandLine(cmd *cobra.Command, name string) bool {
+	return cmd.Flags().Changed(name) && !configuredFlags[name]
+}
+
+// applyConfig sets the flags of cmd that weren't given on the command line from
+// the config file, so flags always take precedence. Settings for flags of other
+// commands are skipped; settings no command knows are an error, to catch typos.
+func applyConfig(cmd *cobra.Command) error {
+	settings, path, err := loadConfig()
+	if err != nil || settings == nil {
+		return err
+	}
+
+	for name, value := range settings {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			if !anyCommandHasFlag(cmd.Root(), name) {
+				return fmt.Errorf("unknown setting %q in config file %s", name, path)
+			}
+			continue
+		}
+		if flag.Changed {
+			continue
+		}
+		if override, ok := overriddenSettings[name]; ok && setOnCommandLine(cmd, override) {
+			continue
+		}
+		configuredFlags[name] = true
+
+		values := []interface{}{value}
+		if list, ok := value.([]interface{}); ok {
+			values = list
+		}
+		for _, v := range values {
+			if err := cmd.Flags().Set(name, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("invalid value for %q in config file %s: %v", name, path, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// anyCommandHasFlag reports whether cmd or any of its subcommands has the named flag
+func anyCommandHasFlag(cmd *cobra.Command, name string) bool {
+	if cmd.Flags().Lookup(name) != nil || cmd.PersistentFlags().Lookup(name) != nil {
+		return true
+	}
+	for _, sub := range cmd.Commands() {
+		if anyCommandHasFlag(sub, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// newDriftCmd creates the `drift` command, which diffs a saved snapshot against
+// the live cluster
+func newDriftCmd() *cobra.Command {
+	var (
+		flags       captureFlags
+		compare     compareFlags
+		output      outputFlags
+		snapshotDir string
+		note        string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "drift <baseline.json>",
+		Short: "Compare a saved snapshot against the current cluster state",
+		Long: "Loads a snapshot saved by k8s-rdiff, captures the current state of the cluster and\n" +
+			"prints what changed since the snapshot was taken, e.g. for scheduled drift checks.\n" +
+			"Unless --namespace or --all-namespaces is given, the baseline's namespace is used.\n\n" +
+			"Exits with 0 when no changes were detected, 2 when changes were detected and 3 on error.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := output.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			if err := compare.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+
+			baseline, err := snapshot.LoadFromFile(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading baseline: %v\n", err)
+				os.Exit(exitError)
+			}
+			// A namespace from the config file is for captures, not a reason to leave the baseline's
+			if !setOnCommandLine(cmd, "namespace") && !setOnCommandLine(cmd, "all-namespaces") {
+				flags.namespace = baseline.Namespace
+				flags.allNamespaces = baseline.Namespace == ""
+			}
+
+			if err := flags.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			selector, _ := flags.selector()
+
+			captureOpts := flags.captureOptions(os.Stderr)
+			fmt.Fprint(os.Stderr, "Capturing current state... ")
+			current, err := snapshot.Capture(context.Background(), captureOpts)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "failed!")
+				fmt.Fprintf(os.Stderr, "Error capturing current state: %v\n", err)
+				os.Exit(exitError)
+			}
+			fmt.Fprintln(os.Stderr, "done!")
+			printProfile(os.Stderr, "current", current)
+			if snapshotDir != "" {
+				if err := saveSnapshot(os.Stderr, snapshotDir, "current", note, current); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(exitError)
+				}
+			}
+
+			if clusters := snapshot.DescribeClusters(baseline, current); clusters != "" {
+				fmt.Fprintf(os.Stderr, "Cluster: %s\n", clusters)
+			}
+			printNotes(os.Stderr, baseline, current)
+			if len(current.FailedTypes) > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: current snapshot is partial, these types failed to list: %s\n",
+					strings.Join(current.FailedTypes, ", "))
+			}
+
+			compareOpts := compare.compareOptions()
+			compareOpts.CollectUnchanged = output.showUnchanged
+			result := diff.CompareWithOptions(baseline, current, compareOpts).
+				FilterByLabels(selector).
+				ExcludeNamespaces(output.hideNamespaces)
+			os.Exit(printDiff(result, output))
+		},
+	}
+
+	addCaptureFlags(cmd, &flags)
+	addCompareFlags(cmd, &compare)
+	addOutputFlags(cmd, &output)
+	cmd.Flags().StringVar(&snapshotDir, "snapshot-dir", "", "Save the current snapshot into this directory, e.g. as the baseline of the next drift check")
+	cmd.Flags().StringVar(&note, "note", "", "Free-text note saved in the --snapshot-dir snapshot and shown when it's compared, e.g. \"deployed v2.3\"")
+
+	return cmd
+}
@@ -0,0 +1,561 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+	"github.com/winson-sou/k8s-rdiff/internal/filter"
+	"github.com/winson-sou/k8s-rdiff/internal/k8s"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// defaultMaxResources guards against accidentally listing a huge cluster in full
+const defaultMaxResources = 100000
+
+// captureFlags holds the flags shared by every command that captures snapshots
+type captureFlags struct {
+	namespace               string
+	namespaceConcurrency    int
+	allNamespaces           bool
+	ignorePattern           string
+	ignoreGlobs             []string
+	includePattern          string
+	myCRDs                  []string
+	namePattern             string
+	fieldSelector           string
+	apiGroups               []string
+	kind                    string
+	kindsFile               string
+	kinds                   []string // Loaded from kindsFile by validate
+	maxResources            int
+	normalizeLists          bool
+	strict                  bool
+	stripAnnotations        []string
+	keepSecretValues        bool
+	volatileFields          []string
+	normalizeCmd            string
+	normalizeTimeout        time.Duration
+	listNormalizations      []string
+	kubeconfigPath          string
+	kubeContext             string
+	as                      string
+	asGroups                []string
+	asUID                   string
+	qps                     float32
+	burst                   int
+	protobuf                bool
+	cachedReads             bool
+	useDefaultExclusions    bool
+	includeSystemNamespaces bool
+	includeSATokens         bool
+	ignoreManagedBy         []string
+	systemNamespaces        []string
+	extraSystemNamespaces   []string
+	resultSelector          string
+	profile                 bool
+}
+
+// compareFlags holds the flags shared by every command that compares snapshots
+type compareFlags struct {
+	ignoreResourceVersion  bool
+	trackGeneration        bool
+	ignoreAnnotations      []string
+	ignoreAnnotationsRegex string
+	severities             []string
+	watchFields            []string
+	immutableFields        []string
+}
+
+// outputFlags holds the flags shared by every command that prints a diff
+type outputFlags struct {
+	format         string
+	contextLines   int
+	showAge        bool
+	compact        bool
+	hideNamespaces []string
+	viewNamespace  string
+	minSeverity    string
+	maxPerKind     int
+	only           []string
+	onlyKinds      []string
+	onlyNamespaces []string
+	onlyName       string
+	template       string
+	templateScope  string
+	showUnchanged  bool
+
+	parsedTemplate *template.Template // Set by validate when --template is given
+}
+
+// addOutputFlags registers the shared diff output flags on a command
+func addOutputFlags(cmd *cobra.Command, f *outputFlags) {
+	cmd.Flags().StringVarP(&f.format, "output", "o", "table", "Output format (table, tree, summary, json, yaml, csv, junit, diff)")
+	cmd.Flags().IntVar(&f.contextLines, "context-lines", diff.DefaultContextLines, "Unchanged lines shown around each change with --output diff")
+	cmd.Flags().StringSliceVar(&f.hideNamespaces, "hide-namespace", nil, "Hide resources in these namespaces from the diff output (they are still captured)")
+	cmd.Flags().BoolVar(&f.compact, "compact", false, "Print JSON output on a single line instead of pretty-printed")
+	cmd.Flags().BoolVar(&f.showAge, "show-age", false, "Add an AGE column to the table output, computed from each resource's creation timestamp")
+	cmd.Flags().StringVar(&f.minSeverity, "min-severity", "low", "Only report changes of at least this severity (low, medium, high, critical)")
+	cmd.Flags().StringSliceVar(&f.only, "only", nil, "Only show these types of change: added, removed, modified (e.g. added,modified)")
+	cmd.Flags().StringSliceVar(&f.onlyKinds, "only-kind", nil, "Only show changes to these kinds, by name or full type (e.g. Deployment,ConfigMap or apps/v1/Deployment)")
+	cmd.Flags().StringSliceVar(&f.onlyNamespaces, "only-namespace", nil, "Only show changes in these namespaces (\"\" for cluster-scoped resources)")
+	cmd.Flags().StringVar(&f.viewNamespace, "view-namespace", "", "Only show the changes in this one namespace of an --all-namespaces capture, without capturing it again")
+	cmd.Flags().StringVar(&f.onlyName, "only-name", "", "Only show changes to resources whose names match this regex")
+	cmd.Flags().IntVar(&f.maxPerKind, "max-per-kind", 0, "Show at most this many changes of each kind, followed by \"… and N more\" (0 for no limit)")
+	cmd.Flags().StringVar(&f.template, "template", "", "Format the output with a Go template instead of --output (see README for the fields and helpers)")
+	cmd.Flags().StringVar(&f.templateScope, "template-scope", "resource", "What --template is executed with: each changed resource, or the whole result (resource, result)")
+
+	// Debugging aid for changes that weren't detected
+	cmd.Flags().BoolVar(&f.showUnchanged, "show-unchanged", false, "Also list, on stderr, the resources in both snapshots considered unchanged, with their hashes")
+	cmd.Flags().MarkHidden("show-unchanged")
+}
+
+// validate checks the output format and context lines
+func (f *outputFlags) validate() error {
+	switch strings.ToLower(f.format) {
+	case "table", "tree", "summary", "json", "yaml", "csv", "junit", "diff":
+	default:
+		return fmt.Errorf("unsupported output format %q (use table, tree, summary, json, yaml, csv, junit or diff)", f.format)
+	}
+
+	if f.contextLines < 0 {
+		return fmt.Errorf("invalid context lines %d: must be 0 or more", f.contextLines)
+	}
+
+	if _, err := diff.ParseSeverity(f.minSeverity); err != nil {
+		return fmt.Errorf("invalid --min-severity: %v", err)
+	}
+
+	for _, op := range f.only {
+		if _, err := diff.ParseDiffType(op); err != nil {
+			return fmt.Errorf("invalid --only: %v", err)
+		}
+	}
+	if _, err := regexp.Compile(f.onlyName); err != nil {
+		return fmt.Errorf("invalid --only-name %q: %v", f.onlyName, err)
+	}
+
+	if f.maxPerKind < 0 {
+		return fmt.Errorf("invalid --max-per-kind %d: must be 0 or more", f.maxPerKind)
+	}
+
+	if f.templateScope != "resource" && f.templateScope != "result" {
+		return fmt.Errorf("invalid --template-scope %q (use resource or result)", f.templateScope)
+	}
+	if f.template != "" {
+		tmpl, err := diff.ParseTemplate(f.template)
+		if err != nil {
+			return fmt.Errorf("invalid --template: %v", err)
+		}
+		f.parsedTemplate = tmpl
+	}
+	return nil
+}
+
+// filterOptions converts the --only* flags into diff filter options; call validate first
+func (f *outputFlags) filterOptions() diff.FilterOptions {
+	opts := diff.FilterOptions{
+		Kinds:      f.onlyKinds,
+		Namespaces: f.onlyNamespaces,
+	}
+	for _, op := range f.only {
+		parsed, _ := diff.ParseDiffType(op)
+		opts.Operations = append(opts.Operations, parsed)
+	}
+	if f.onlyName != "" {
+		opts.NamePattern = regexp.MustCompile(f.onlyName)
+	}
+	return opts
+}
+
+// severity returns the parsed --min-severity; call validate first
+func (f *outputFlags) severity() diff.Severity {
+	severity, _ := diff.ParseSeverity(f.minSeverity)
+	return severity
+}
+
+// addCompareFlags registers the shared comparison flags on a command
+func addCompareFlags(cmd *cobra.Command, f *compareFlags) {
+	cmd.Flags().BoolVar(&f.ignoreResourceVersion, "ignore-resource-version", false, "Ignore resourceVersion bumps and only report resources whose spec, labels or annotations changed")
+
+	// Earlier name of --ignore-resource-version
+	cmd.Flags().BoolVar(&f.ignoreResourceVersion, "diff-spec-only", false, "Ignore resourceVersion bumps and only report resources whose spec, labels or annotations changed")
+	cmd.Flags().MarkDeprecated("diff-spec-only", "use --ignore-resource-version instead")
+
+	cmd.Flags().BoolVar(&f.trackGeneration, "resource-version-in-key", false, "Also report resources whose metadata.generation changed, e.g. a spec changed and reverted to the same content (for audit trails)")
+
+	cmd.Flags().StringSliceVar(&f.ignoreAnnotations, "annotations-ignore", nil, "Annotations whose changes don't mark a resource modified, in addition to common GitOps ones (see 'k8s-rdiff list')")
+	cmd.Flags().StringVar(&f.ignoreAnnotationsRegex, "ignore-annotations-regex", "", "Also ignore changes to annotations whose keys match this regex, e.g. '\\.fluxcd\\.io/'")
+	cmd.Flags().StringSliceVar(&f.watchFields, "watch-fields", nil, "Only report a resource modified when one of these fields changed, e.g. spec.replicas,spec.template.spec.containers[*].image")
+	cmd.Flags().StringArrayVar(&f.severities, "severity", nil, "Override the severity of a kind as KIND=SEVERITY, e.g. ConfigMap=high or apps/v1/Deployment=critical (repeatable)")
+	cmd.Flags().StringArrayVar(&f.immutableFields, "immutable-field", nil, "Also flag changes to this field of a kind as needing recreation, as KIND=FIELD, e.g. MyDatabase=spec.engine (repeatable)")
+}
+
+// validate checks the annotation regex, the severity overrides and the watched
+// and immutable field paths
+func (f *compareFlags) validate() error {
+	if _, err := regexp.Compile(f.ignoreAnnotationsRegex); err != nil {
+		return fmt.Errorf("invalid --ignore-annotations-regex %q: %v", f.ignoreAnnotationsRegex, err)
+	}
+	for _, rule := range f.severities {
+		if _, _, err := diff.ParseSeverityMapping(rule); err != nil {
+			return err
+		}
+	}
+	for _, path := range f.watchFields {
+		if _, err := diff.ParseFieldPath(path); err != nil {
+			return err
+		}
+	}
+	for _, rule := range f.immutableFields {
+		if _, _, err := diff.ParseImmutableField(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareOptions converts the flags into diff comparison options; call validate first
+func (f *compareFlags) compareOptions() diff.CompareOptions {
+	opts := diff.CompareOptions{
+		IgnoreResourceVersion: f.ignoreResourceVersion,
+		TrackGeneration:       f.trackGeneration,
+		IgnoreAnnotations:     append(filter.DefaultIgnoredAnnotations(), f.ignoreAnnotations...),
+	}
+	if f.ignoreAnnotationsRegex != "" {
+		opts.IgnoreAnnotationsRegex = regexp.MustCompile(f.ignoreAnnotationsRegex)
+	}
+
+	if len(f.severities) > 0 {
+		opts.Severities = make(map[string]diff.Severity, len(f.severities))
+		for _, rule := range f.severities {
+			kind, severity, _ := diff.ParseSeverityMapping(rule)
+			opts.Severities[kind] = severity
+		}
+	}
+	for _, path := range f.watchFields {
+		parsed, _ := diff.ParseFieldPath(path)
+		opts.WatchFields = append(opts.WatchFields, parsed)
+	}
+	if len(f.immutableFields) > 0 {
+		opts.ImmutableFields = map[string][]diff.FieldPath{}
+		for _, rule := range f.immutableFields {
+			kind, path, _ := diff.ParseImmutableField(rule)
+			opts.ImmutableFields[kind] = append(opts.ImmutableFields[kind], path)
+		}
+	}
+	return opts
+}
+
+// addCaptureFlags registers the shared capture flags on a command
+func addCaptureFlags(cmd *cobra.Command, f *captureFlags) {
+	cmd.Flags().StringVarP(&f.namespace, "namespace", "n", "", "Kubernetes namespace to monitor, or a comma-separated list of namespaces to capture concurrently")
+	cmd.Flags().IntVar(&f.namespaceConcurrency, "namespace-concurrency", snapshot.DefaultNamespaceConcurrency, "How many of several --namespace namespaces are listed at once")
+	cmd.Flags().BoolVarP(&f.allNamespaces, "all-namespaces", "A", false, "Monitor all namespaces instead of a single --namespace")
+	cmd.Flags().StringVarP(&f.ignorePattern, "ignore", "i", "", "Regex pattern to ignore additional resource kinds")
+	cmd.Flags().StringSliceVar(&f.ignoreGlobs, "ignore-glob", nil, "Glob pattern of resource kinds to ignore, e.g. '*/Event' or 'apps/v1/*' (repeatable)")
+	cmd.Flags().StringSliceVar(&f.myCRDs, "my-crds", nil, "API groups whose kinds are always captured, e.g. the CRDs of your own operators, even if they match an exclusion (repeatable)")
+	cmd.Flags().StringVar(&f.includePattern, "include", "", "Regex pattern of resource kinds to keep even if they match an exclusion")
+	cmd.Flags().StringVar(&f.namePattern, "name", "", "Regex pattern of resource names to capture (composes with kind filtering)")
+	cmd.Flags().StringVar(&f.fieldSelector, "field-selector", "", "Server-side field selector for listing (e.g. metadata.name=web); types that don't support it are listed in full")
+	cmd.Flags().StringArrayVar(&f.apiGroups, "api-group", nil, "Only capture resources in this API group (repeatable; use \"core\" for the core group)")
+	cmd.Flags().StringVar(&f.kind, "kind", "", "Only capture this kind (e.g. Deployment or deployments.apps), skipping full discovery")
+	cmd.Flags().StringVar(&f.kindsFile, "kinds-file", "", "Capture exactly the resource types in this file, one GROUP/VERSION/KIND per line (e.g. apps/v1/Deployment), skipping discovery and the type filters")
+	cmd.Flags().StringVarP(&f.kubeconfigPath, "kubeconfig", "k", "", "Path to kubeconfig file (defaults to the merged KUBECONFIG files or ~/.kube/config)")
+	cmd.Flags().StringVar(&f.kubeContext, "context", "", "Kubeconfig context to use instead of the current context")
+	cmd.Flags().StringVar(&f.as, "as", "", "Username to impersonate for the capture, e.g. system:serviceaccount:ns:sa (the capture then respects its RBAC)")
+	cmd.Flags().StringArrayVar(&f.asGroups, "as-group", nil, "Group to impersonate (repeatable)")
+	cmd.Flags().StringVar(&f.asUID, "as-uid", "", "UID to impersonate")
+	cmd.Flags().Float32Var(&f.qps, "qps", k8s.DefaultQPS, "Maximum API requests per second; lower it if the API server is struggling")
+	cmd.Flags().IntVar(&f.burst, "burst", k8s.DefaultBurst, "Maximum burst of API requests above --qps")
+	cmd.Flags().BoolVar(&f.protobuf, "protobuf", false, "List built-in resource types as protobuf for faster, smaller responses (CRDs fall back to JSON)")
+	cmd.Flags().BoolVar(&f.cachedReads, "cached-reads", false, "List from the API server's watch cache instead of etcd, which is lighter on busy clusters but may capture slightly stale state")
+	cmd.Flags().BoolVarP(&f.useDefaultExclusions, "exclude-noisy", "e", true, "Exclude noisy resources like Events, Endpoints, etc.")
+	cmd.Flags().BoolVarP(&f.includeSystemNamespaces, "include-system", "s", false, "Include system namespaces (kube-system, etc.)")
+	cmd.Flags().BoolVar(&f.includeSATokens, "include-sa-tokens", false, "Keep ServiceAccount token Secrets generated by Kubernetes, which are excluded by default")
+	cmd.Flags().StringSliceVar(&f.ignoreManagedBy, "ignore-managed-by", nil, "Exclude resources whose app.kubernetes.io/managed-by label is one of these tools, e.g. Helm,Tilt (case-insensitive)")
+	cmd.Flags().StringSliceVar(&f.systemNamespaces, "system-namespaces", nil, "Namespaces to treat as system namespaces, replacing the default list")
+	cmd.Flags().StringSliceVar(&f.extraSystemNamespaces, "system-namespaces-extra", nil, "Namespaces to treat as system namespaces in addition to the default list (e.g. istio-system,monitoring)")
+	cmd.Flags().IntVar(&f.maxResources, "max-resources", defaultMaxResources, "Stop capturing once more than this many objects are listed (0 for no limit); the TUI asks before going past it")
+	cmd.Flags().BoolVar(&f.normalizeLists, "normalize-lists", false, "Sort env vars and ports before hashing specs so reordering them isn't reported as a change")
+	cmd.Flags().StringArrayVar(&f.listNormalizations, "normalize-list", nil, "Additional order-insensitive list as FIELD=KEY[,KEY...] (e.g. volumeMounts=mountPath); implies --normalize-lists (repeatable)")
+	cmd.Flags().BoolVar(&f.strict, "strict", false, "Fail the capture if any resource type can't be discovered or listed instead of diffing a partial snapshot")
+	cmd.Flags().StringSliceVar(&f.stripAnnotations, "strip-annotations", nil, "Annotations to drop from captured manifests, in addition to kubectl.kubernetes.io/last-applied-configuration")
+	cmd.Flags().BoolVar(&f.keepSecretValues, "keep-secret-values", false, "Store Secret values in captured manifests as listed instead of a keyed digest, so the detail view shows how they changed; snapshots then hold the secrets")
+	cmd.Flags().StringSliceVar(&f.volatileFields, "volatile-fields", nil, "Dotted fields left out of resource hashes, in addition to status and metadata bookkeeping like resourceVersion and managedFields (e.g. spec.replicas)")
+	cmd.Flags().StringVar(&f.normalizeCmd, "normalize-cmd", "", "Shell command every captured resource is piped through as JSON (stdin to stdout) before hashing, e.g. 'jq \"del(.spec.replicas)\"'")
+	cmd.Flags().DurationVar(&f.normalizeTimeout, "normalize-timeout", snapshot.DefaultNormalizeTimeout, "How long --normalize-cmd may take per resource before the resource is kept as listed")
+	cmd.Flags().BoolVar(&f.profile, "profile", false, "Print the slowest resource types to list after each capture (helps tune --ignore)")
+	cmd.Flags().StringVar(&f.resultSelector, "result-selector", "", "Label selector to show only matching resources in the diff (e.g. team=payments)")
+}
+
+// validate checks the flag values that would otherwise only fail once a capture starts
+func (f *captureFlags) validate() error {
+	if f.namespace == "" && !f.allNamespaces {
+		return fmt.Errorf("no namespace given: pass --namespace <namespace>, or --all-namespaces (-A) to capture the whole cluster")
+	}
+	if f.namespace != "" && f.allNamespaces {
+		return fmt.Errorf("--namespace and --all-namespaces can't be combined")
+	}
+	if namespaces := f.namespaces(); len(namespaces) > 1 {
+		seen := map[string]bool{}
+		for _, namespace := range namespaces {
+			if namespace == "" || seen[namespace] {
+				return fmt.Errorf("invalid --namespace %q: list each namespace once, separated by commas", f.namespace)
+			}
+			seen[namespace] = true
+		}
+	}
+	if f.namespaceConcurrency < 1 {
+		return fmt.Errorf("invalid --namespace-concurrency %d: must be 1 or more", f.namespaceConcurrency)
+	}
+
+	if f.kindsFile != "" {
+		if f.kind != "" {
+			return fmt.Errorf("--kind and --kinds-file can't be combined")
+		}
+		kinds, err := snapshot.LoadKindsFile(f.kindsFile)
+		if err != nil {
+			return fmt.Errorf("invalid --kinds-file: %v", err)
+		}
+		f.kinds = kinds
+	}
+
+	if f.as == "" && (len(f.asGroups) > 0 || f.asUID != "") {
+		return fmt.Errorf("--as-group and --as-uid require --as")
+	}
+
+	if f.ignorePattern != "" {
+		if _, err := regexp.Compile(f.ignorePattern); err != nil {
+			return fmt.Errorf("invalid ignore pattern %q: %v", f.ignorePattern, err)
+		}
+	}
+
+	if f.includePattern != "" {
+		if _, err := regexp.Compile(f.includePattern); err != nil {
+			return fmt.Errorf("invalid include pattern %q: %v", f.includePattern, err)
+		}
+	}
+
+	for _, group := range f.myCRDs {
+		if group == "" || strings.Contains(group, "/") {
+			return fmt.Errorf("invalid --my-crds group %q: must be an API group like example.com, without a version", group)
+		}
+	}
+
+	if f.namePattern != "" {
+		if _, err := regexp.Compile(f.namePattern); err != nil {
+			return fmt.Errorf("invalid name pattern %q: %v", f.namePattern, err)
+		}
+	}
+
+	for _, rule := range f.listNormalizations {
+		if _, err := snapshot.ParseListNormalization(rule); err != nil {
+			return err
+		}
+	}
+
+	for _, field := range f.volatileFields {
+		if field == "" || strings.HasPrefix(field, ".") || strings.HasSuffix(field, ".") || strings.Contains(field, "..") {
+			return fmt.Errorf("invalid --volatile-fields entry %q: expected a dotted path like spec.replicas", field)
+		}
+	}
+
+	if f.normalizeTimeout <= 0 {
+		return fmt.Errorf("invalid --normalize-timeout %s: must be greater than 0", f.normalizeTimeout)
+	}
+
+	if f.qps <= 0 || f.burst <= 0 {
+		return fmt.Errorf("--qps and --burst must be greater than 0")
+	}
+
+	if f.maxResources < 0 {
+		return fmt.Errorf("invalid max resources %d: must be 0 (no limit) or more", f.maxResources)
+	}
+
+	if f.fieldSelector != "" {
+		if _, err := fields.ParseSelector(f.fieldSelector); err != nil {
+			return fmt.Errorf("invalid field selector %q: %v", f.fieldSelector, err)
+		}
+	}
+
+	if _, err := f.selector(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// selector parses the --result-selector flag
+func (f *captureFlags) selector() (labels.Selector, error) {
+	selector, err := labels.Parse(f.resultSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid result selector %q: %v", f.resultSelector, err)
+	}
+	return selector, nil
+}
+
+// namespaces splits a comma-separated --namespace into its namespaces
+func (f *captureFlags) namespaces() []string {
+	if f.namespace == "" {
+		return nil
+	}
+	return strings.Split(f.namespace, ",")
+}
+
+// systemNamespaceList resolves --system-namespaces and --system-namespaces-extra
+// against the default system namespaces
+func (f *captureFlags) systemNamespaceList() []string {
+	namespaces := filter.CommonSystemNamespaces()
+	if len(f.systemNamespaces) > 0 {
+		namespaces = append([]string{}, f.systemNamespaces...)
+	}
+	return append(namespaces, f.extraSystemNamespaces...)
+}
+
+// listNormalizationRules resolves --normalize-lists and --normalize-list. The rules
+// are expected to have been checked by validate.
+func (f *captureFlags) listNormalizationRules() []snapshot.ListNormalization {
+	if !f.normalizeLists && len(f.listNormalizations) == 0 {
+		return nil
+	}
+
+	rules := snapshot.DefaultListNormalizations()
+	for _, rule := range f.listNormalizations {
+		if parsed, err := snapshot.ParseListNormalization(rule); err == nil {
+			rules = append(rules, parsed)
+		}
+	}
+	return rules
+}
+
+// captureOptions converts the flags into snapshot capture options, describing the
+// filtering that will be applied on w
+func (f *captureFlags) captureOptions(w io.Writer) snapshot.CaptureOptions {
+	// Display information about what's happening
+	if f.useDefaultExclusions {
+		fmt.Fprintln(w, "Filtering out noisy resources (events, endpoints, etc)...")
+		if f.ignorePattern != "" {
+			fmt.Fprintf(w, "Also excluding resources matching pattern: %s\n", f.ignorePattern)
+		}
+	} else if f.ignorePattern != "" {
+		fmt.Fprintf(w, "Excluding only resources matching pattern: %s\n", f.ignorePattern)
+	} else if len(f.ignoreGlobs) == 0 {
+		fmt.Fprintln(w, "No resource filtering applied")
+	}
+
+	if len(f.ignoreGlobs) > 0 {
+		fmt.Fprintf(w, "Excluding resources matching globs: %s\n", strings.Join(f.ignoreGlobs, ", "))
+	}
+
+	ignoreGlobs := f.ignoreGlobs
+	fileGlobs, err := filter.LoadIgnoreFile(filter.IgnoreFileName)
+	if err != nil {
+		fmt.Fprintf(w, "Warning: %v\n", err)
+	} else if len(fileGlobs) > 0 {
+		fmt.Fprintf(w, "Excluding resources listed in %s: %s\n", filter.IgnoreFileName, strings.Join(fileGlobs, ", "))
+		ignoreGlobs = append(append([]string{}, f.ignoreGlobs...), fileGlobs...)
+	}
+
+	if f.includePattern != "" {
+		fmt.Fprintf(w, "Always including resources matching pattern: %s\n", f.includePattern)
+	}
+
+	if len(f.myCRDs) > 0 {
+		fmt.Fprintf(w, "Always including resources in API groups: %s\n", strings.Join(f.myCRDs, ", "))
+	}
+
+	if f.namePattern != "" {
+		fmt.Fprintf(w, "Only capturing resources named: %s\n", f.namePattern)
+	}
+
+	systemNamespaces := f.systemNamespaceList()
+	if !f.includeSystemNamespaces {
+		fmt.Fprintf(w, "Excluding system namespaces: %s (use --include-system to keep them)\n", strings.Join(systemNamespaces, ", "))
+	}
+
+	if !f.includeSATokens {
+		fmt.Fprintln(w, "Excluding generated ServiceAccount token secrets (use --include-sa-tokens to keep them)")
+	}
+
+	if len(f.ignoreManagedBy) > 0 {
+		fmt.Fprintf(w, "Excluding resources managed by: %s\n", strings.Join(f.ignoreManagedBy, ", "))
+	}
+
+	if f.fieldSelector != "" {
+		fmt.Fprintf(w, "Listing with field selector: %s\n", f.fieldSelector)
+	}
+
+	if f.kind != "" {
+		fmt.Fprintf(w, "Only capturing kind: %s\n", f.kind)
+	}
+
+	if len(f.kinds) > 0 {
+		fmt.Fprintf(w, "Only capturing the %d resource types in %s\n", len(f.kinds), f.kindsFile)
+	}
+
+	if len(f.apiGroups) > 0 {
+		fmt.Fprintf(w, "Only capturing API groups: %s\n", strings.Join(f.apiGroups, ", "))
+	}
+
+	// Without a key that persists, digests only match within this run
+	var secretDigestKey []byte
+	if !f.keepSecretValues {
+		if secretDigestKey, err = loadSecretDigestKey(); err != nil {
+			fmt.Fprintf(w, "Warning: %v; Secret digests won't match those of other runs\n", err)
+		}
+	}
+
+	// Several namespaces are captured concurrently instead of all of them
+	namespace, namespaces := f.namespace, []string(nil)
+	if list := f.namespaces(); len(list) > 1 {
+		namespace, namespaces = "", list
+	}
+
+	return snapshot.CaptureOptions{
+		Namespace:               namespace,
+		AllNamespaces:           f.allNamespaces,
+		Namespaces:              namespaces,
+		NamespaceConcurrency:    f.namespaceConcurrency,
+		KubeconfigPath:          f.kubeconfigPath,
+		Context:                 f.kubeContext,
+		As:                      f.as,
+		AsGroups:                f.asGroups,
+		AsUID:                   f.asUID,
+		QPS:                     f.qps,
+		Burst:                   f.burst,
+		Protobuf:                f.protobuf,
+		CachedReads:             f.cachedReads,
+		ExcludeNoisy:            f.useDefaultExclusions,
+		IgnorePattern:           f.ignorePattern,
+		IgnoreGlobs:             ignoreGlobs,
+		IncludePattern:          f.includePattern,
+		IncludeGroups:           f.myCRDs,
+		NamePattern:             f.namePattern,
+		FieldSelector:           f.fieldSelector,
+		APIGroups:               f.apiGroups,
+		Kind:                    f.kind,
+		Kinds:                   f.kinds,
+		MaxResources:            f.maxResources,
+		IncludeSystemNamespaces: f.includeSystemNamespaces,
+		IncludeSATokens:         f.includeSATokens,
+		IgnoreManagedBy:         f.ignoreManagedBy,
+		SystemNamespaces:        systemNamespaces,
+		RecordTimings:           f.profile,
+		Strict:                  f.strict,
+		StripAnnotations:        append(filter.DefaultStrippedAnnotations(), f.stripAnnotations...),
+		KeepSecretValues:        f.keepSecretValues,
+		SecretDigestKey:         secretDigestKey,
+		VolatileFields:          append(snapshot.DefaultVolatileFields(), f.volatileFields...),
+		ListNormalizations:      f.listNormalizationRules(),
+		NormalizeCmd:            f.normalizeCmd,
+		NormalizeTimeout:        f.normalizeTimeout,
+	}
+}
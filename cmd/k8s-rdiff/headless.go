@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+	"github.com/winson-sou/k8s-rdiff/internal/ui"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Exit codes used by the non-interactive commands
+const (
+	exitNoChanges = 0
+	exitChanges   = 2
+	exitError     = 3
+)
+
+// headlessOptions holds the extra settings of the non-interactive flow
+type headlessOptions struct {
+	outputDir       string // Also write a diff file per changed resource here
+	snapshotDir     string // Save both snapshots here
+	note            string // Stored in the saved snapshots
+	exec            string // Run this command instead of waiting for 'continue'
+	execIgnoreError bool   // Diff even if the command fails
+	bench           bool   // Print how long capturing and diffing took
+}
+
+// runHeadless mirrors the TUI flow on a plain terminal: capture a baseline, wait for
+// the user to type 'continue' (or run opts.exec), capture the current state and print
+// the diff. Progress goes to stderr so only the diff lands on stdout. It returns the
+// process exit code.
+func runHeadless(captureOpts snapshot.CaptureOptions, compareOpts diff.CompareOptions, selector labels.Selector, output outputFlags, opts headlessOptions) int {
+	msgs := os.Stderr
+	dialog := ui.NewDialog().WithOutput(msgs)
+
+	fmt.Fprint(msgs, "Capturing baseline... ")
+	start := time.Now()
+	baseline, err := snapshot.Capture(context.Background(), captureOpts)
+	baselineDuration := time.Since(start)
+	if err != nil {
+		fmt.Fprintln(msgs, "failed!")
+		fmt.Fprintf(os.Stderr, "Error capturing baseline: %v\n", err)
+		return exitError
+	}
+	fmt.Fprintln(msgs, "done!")
+	fmt.Fprintf(msgs, "Baseline captured at %s\n", baseline.Timestamp.Format(time.RFC3339))
+	if summary := baseline.ClusterSummary(); summary != "" {
+		fmt.Fprintf(msgs, "Cluster: %s\n", summary)
+	}
+	printProfile(msgs, "baseline", baseline)
+	if opts.snapshotDir != "" {
+		if err := saveSnapshot(msgs, opts.snapshotDir, "baseline", opts.note, baseline); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+	}
+
+	if opts.exec != "" {
+		if err := dialog.RunCommand(opts.exec); err != nil {
+			if !opts.execIgnoreError {
+				fmt.Fprintf(os.Stderr, "Error: %v (pass --exec-ignore-error to diff anyway)\n", err)
+				return exitError
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	} else if err := dialog.WaitForUserAction(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitError
+	}
+
+	fmt.Fprint(msgs, "Capturing current state... ")
+	start = time.Now()
+	current, err := snapshot.Capture(context.Background(), captureOpts)
+	currentDuration := time.Since(start)
+	if err != nil {
+		fmt.Fprintln(msgs, "failed!")
+		fmt.Fprintf(os.Stderr, "Error capturing current state: %v\n", err)
+		return exitError
+	}
+	fmt.Fprintln(msgs, "done!")
+	printProfile(msgs, "current", current)
+	if opts.snapshotDir != "" {
+		if err := saveSnapshot(msgs, opts.snapshotDir, "current", opts.note, current); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+	}
+	if clusters := snapshot.DescribeClusters(baseline, current); clusters != baseline.ClusterSummary() {
+		fmt.Fprintf(msgs, "Cluster: %s\n", clusters)
+	}
+	fmt.Fprintln(msgs)
+
+	for _, snap := range []*snapshot.Snapshot{baseline, current} {
+		if len(snap.FailedTypes) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: snapshot from %s is partial, these types failed to list: %s\n",
+				snap.Timestamp.Format(time.RFC3339), strings.Join(snap.FailedTypes, ", "))
+		}
+	}
+
+	start = time.Now()
+	result := diff.CompareWithOptions(baseline, current, compareOpts).
+		FilterByLabels(selector).
+		ExcludeNamespaces(output.hideNamespaces)
+	if opts.bench {
+		fmt.Fprintf(msgs, "Timings: baseline capture %s (%d resources), current capture %s (%d resources), diff %s\n",
+			round(baselineDuration), len(baseline.Resources), round(currentDuration), len(current.Resources), round(time.Since(start)))
+	}
+	if opts.outputDir != "" {
+		written, err := diff.WriteDiffFiles(result, opts.outputDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing diff files: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(msgs, "Wrote %d diff file(s) to %s\n", written, opts.outputDir)
+	}
+
+	return printDiff(result, output)
+}
+
+// saveSnapshot saves snap into dir, with the note if one is given, and reports
+// where it was written
+func saveSnapshot(w io.Writer, dir, label, note string, snap *snapshot.Snapshot) error {
+	if note != "" {
+		snap.Note = note
+	}
+	path, err := snap.SaveToFile(dir)
+	if err != nil {
+		return fmt.Errorf("failed to save %s snapshot: %v", label, err)
+	}
+	fmt.Fprintf(w, "Saved %s snapshot to %s\n", label, path)
+	return nil
+}
+
+// printNotes writes the notes saved with two snapshots, once if they're the same
+func printNotes(w io.Writer, baseline, current *snapshot.Snapshot) {
+	if baseline.Note == current.Note {
+		if baseline.Note != "" {
+			fmt.Fprintf(w, "Note: %s\n", baseline.Note)
+		}
+		return
+	}
+	if baseline.Note != "" {
+		fmt.Fprintf(w, "Baseline note: %s\n", baseline.Note)
+	}
+	if current.Note != "" {
+		fmt.Fprintf(w, "Current note: %s\n", current.Note)
+	}
+}
+
+// printDiff writes the diff to stdout and any comparison warnings to stderr,
+// returning the matching exit code
+func printDiff(result *diff.DiffResult, output outputFlags) int {
+	if output.viewNamespace != "" {
+		result = result.Filter(diff.FilterOptions{Namespaces: []string{output.viewNamespace}})
+	}
+	result = result.Filter(output.filterOptions())
+	printUnchanged(os.Stderr, result.Unchanged)
+	result = result.FilterBySeverity(output.severity()).
+		LimitPerKind(output.maxPerKind)
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	for _, warning := range result.ImmutableFieldWarnings() {
+		color.New(color.FgRed).Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	for _, kind := range result.TruncatedKinds() {
+		fmt.Fprintf(os.Stderr, "Note: left out %d %s changes beyond --max-per-kind %d\n", result.Truncated[kind], kind, output.maxPerKind)
+	}
+
+	if output.parsedTemplate != nil {
+		if err := diff.OutputTemplate(result, output.parsedTemplate, os.Stdout, output.templateScope == "resource"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: executing --template: %v\n", err)
+			return exitError
+		}
+		if result.IsEmpty() {
+			return exitNoChanges
+		}
+		return exitChanges
+	}
+
+	format := strings.ToLower(output.format)
+	if result.IsEmpty() && (format == "table" || format == "tree" || format == "diff") {
+		fmt.Println("No differences detected")
+		return exitNoChanges
+	}
+
+	switch {
+	case format == "diff":
+		diff.OutputUnified(result, os.Stdout, output.contextLines)
+	case format == "json" && output.compact:
+		diff.OutputCompactJSON(result, os.Stdout)
+	case format == "table" && output.showAge:
+		diff.OutputTableWithOptions(result, os.Stdout, diff.TableOptions{ShowAge: true})
+	default:
+		diff.DisplayDiff(result, format)
+	}
+	if result.IsEmpty() {
+		return exitNoChanges
+	}
+	return exitChanges
+}
+
+// printUnchanged lists the resources considered unchanged (see --show-unchanged).
+// Different hashes mean the difference was ignored, e.g. by --watch-fields.
+func printUnchanged(w io.Writer, unchanged []diff.ResourceDiff) {
+	if len(unchanged) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "Unchanged resources (%d):\n", len(unchanged))
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tNAMESPACE\tNAME\tBASELINE HASH\tCURRENT HASH\tRESOURCE VERSION")
+	for _, res := range unchanged {
+		version := res.OldResourceVersion
+		if res.NewResourceVersion != res.OldResourceVersion {
+			version += " → " + res.NewResourceVersion
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", res.Resource.GroupVersionKind, res.Resource.Namespace,
+			res.Resource.Name, res.OldSpecHash, res.NewSpecHash, version)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// newImagesCmd creates the `images` command, which reports the container images
+// that changed between two saved snapshots
+func newImagesCmd() *cobra.Command {
+	var (
+		compare compareFlags
+		format  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "images <baseline.json> <current.json>",
+		Short: "List the container images that changed between two saved snapshots",
+		Long: "Loads two snapshots saved by k8s-rdiff and prints, for every modified workload\n" +
+			"(Deployments, StatefulSets, DaemonSets, Jobs, CronJobs, Pods, ...), the containers\n" +
+			"whose image changed, e.g. to verify a rollout.\n\n" +
+			"Exits with 0 when no images changed, 2 when some did and 3 on error.",
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			format = strings.ToLower(format)
+			if format != "table" && format != "json" {
+				fmt.Fprintf(os.Stderr, "Error: unsupported output format %q (use table or json)\n", format)
+				os.Exit(exitError)
+			}
+			if err := compare.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+
+			baseline, err := snapshot.LoadFromFile(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading baseline: %v\n", err)
+				os.Exit(exitError)
+			}
+
+			current, err := snapshot.LoadFromFile(args[1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading current snapshot: %v\n", err)
+				os.Exit(exitError)
+			}
+
+			changes := diff.CompareWithOptions(baseline, current, compare.compareOptions()).ImageChanges()
+			if format == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if changes == nil {
+					changes = []diff.ImageChange{}
+				}
+				encoder.Encode(changes)
+			} else if len(changes) == 0 {
+				fmt.Println("No image changes detected")
+			} else {
+				diff.OutputImageChanges(changes, os.Stdout)
+			}
+
+			if len(changes) == 0 {
+				os.Exit(exitNoChanges)
+			}
+			os.Exit(exitChanges)
+		},
+	}
+
+	addCompareFlags(cmd, &compare)
+	cmd.Flags().StringVarP(&format, "output", "o", "table", "Output format (table, json)")
+
+	return cmd
+}
@@ -3,77 +3,230 @@ package main
 import (
 	"fmt"
 	"os"
+	"runtime"
+	"runtime/debug"
 	"strings"
 
 	"github.com/winson-sou/k8s-rdiff/internal/filter"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+	"github.com/muesli/termenv"
 	"github.com/winson-sou/k8s-rdiff/internal/tui"
 	"github.com/spf13/cobra"
 )
 
+// Build information, injected at build time via -ldflags, e.g.
+//
+//	go build -ldflags "-X main.version=v0.2.0 -X main.commit=$(git rev-parse --short HEAD) -X main.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/k8s-rdiff
+var (
+	version   = "dev"
+	commit    = "none"
+	buildDate = "unknown"
+)
+
+// applyColorMode sets whether the CLI and TUI output is colored: auto leaves it to
+// terminal detection, always forces color even when piped and never disables it
+func applyColorMode(mode string) error {
+	switch strings.ToLower(mode) {
+	case "auto":
+	case "always":
+		color.NoColor = false
+		lipgloss.SetColorProfile(termenv.ANSI256)
+	case "never":
+		color.NoColor = true
+		lipgloss.SetColorProfile(termenv.Ascii)
+	default:
+		return fmt.Errorf("invalid --color %q (use auto, always or never)", mode)
+	}
+	return nil
+}
+
+// clientGoVersion returns the version of k8s.io/client-go compiled into the binary
+func clientGoVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == "k8s.io/client-go" {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+
+	return "unknown"
+}
+
+// versionInfo returns the multi-line build information printed by `version` and `--version`
+func versionInfo() string {
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("k8s-rdiff %s\n", version))
+	s.WriteString(fmt.Sprintf("  commit:     %s\n", commit))
+	s.WriteString(fmt.Sprintf("  built:      %s\n", buildDate))
+	s.WriteString(fmt.Sprintf("  client-go:  %s\n", clientGoVersion()))
+	s.WriteString(fmt.Sprintf("  go:         %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH))
+	return s.String()
+}
+
 func main() {
 	var (
-		namespace          string
-		ignorePattern      string
-		kubeconfigPath     string
-		useDefaultExclusions bool
-		includeSystemNamespaces bool
+		startFlags  captureFlags
+		compare     compareFlags
+		headless    bool
+		noClipboard bool
+		formats     []string
+		snapshotDir string
+		note        string
+		hideNs      []string
+		viewNs      string
+		maxPerKind  int
+		focus       string
+		verbose     bool
+		noColor     bool
+		colorMode   string
 	)
 
 	// Root command
 	rootCmd := &cobra.Command{
-		Use:   "k8s-rdiff",
-		Short: "Kubernetes Resource Diff Tool",
-		Long:  "Captures and compares Kubernetes resources before and after actions",
+		Use:     "k8s-rdiff",
+		Short:   "Kubernetes Resource Diff Tool",
+		Long:    "Captures and compares Kubernetes resources before and after actions",
+		Version: version,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if err := applyConfig(cmd); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			if noColor && !cmd.Flags().Changed("color") {
+				colorMode = "never"
+			}
+			if err := applyColorMode(colorMode); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+		},
 	}
+	rootCmd.SetVersionTemplate(versionInfo())
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", "auto", "When to color the output: auto (only on a terminal), always (e.g. when piping to 'less -R') or never")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (same as --color=never)")
 
 	// Start command
 	startCmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start the interactive resource diff utility",
 		Run: func(cmd *cobra.Command, args []string) {
-			// Convert command line options into our resource filter pattern
-			var finalIgnorePattern string
-			
-			// Apply excluded resources
-			if useDefaultExclusions {
-				// We handle this directly in the snapshot.CaptureSnapshot function now
-				// Using the filter.NewResourceFilter().WithNoisy() approach
-				// No need to build a pattern here
-			} else {
-				// If not using default exclusions, use only what the user provided
-				finalIgnorePattern = ignorePattern
-			}
-
-			// Display information about what's happening
-			if useDefaultExclusions {
-				fmt.Println("Filtering out noisy resources (events, endpoints, etc)...")
-				if ignorePattern != "" {
-					fmt.Printf("Also excluding resources matching pattern: %s\n", ignorePattern)
+			// Validate patterns and selectors before launching anything
+			if err := startFlags.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			if err := compare.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			if maxPerKind < 0 {
+				fmt.Fprintf(os.Stderr, "Error: invalid --max-per-kind %d: must be 0 or more\n", maxPerKind)
+				os.Exit(exitError)
+			}
+			if focus != "" {
+				if _, _, err := tui.ParseFocus(focus); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(exitError)
+				}
+				if headless {
+					fmt.Fprintln(os.Stderr, "Error: --focus opens a resource in the TUI and can't be combined with --headless")
+					os.Exit(exitError)
+				}
+			}
+			selector, _ := startFlags.selector()
+
+			for i, format := range formats {
+				formats[i] = strings.ToLower(strings.TrimSpace(format))
+				switch formats[i] {
+				case "table", "tree", "yaml", "json":
+				default:
+					fmt.Fprintf(os.Stderr, "Error: unsupported format %q in --formats (use table, tree, yaml or json)\n", format)
+					os.Exit(exitError)
 				}
-			} else if ignorePattern != "" {
-				fmt.Printf("Excluding only resources matching pattern: %s\n", ignorePattern)
-			} else {
-				fmt.Println("No resource filtering applied")
 			}
 
+			// Use the plain prompt-based flow on terminals without altscreen support.
+			// Like 'run', it keeps stdout for the diff.
+			if headless {
+				captureOpts := startFlags.captureOptions(os.Stderr)
+				os.Exit(runHeadless(captureOpts, compare.compareOptions(), selector, outputFlags{format: "table", hideNamespaces: hideNs, viewNamespace: viewNs, maxPerKind: maxPerKind}, headlessOptions{snapshotDir: snapshotDir, note: note}))
+			}
+
+			// Convert command line options into capture options
+			captureOpts := startFlags.captureOptions(os.Stdout)
+
 			// Start the TUI application
-			model := tui.New(namespace, finalIgnorePattern, kubeconfigPath)
+			model := tui.New(tui.Options{
+				Capture:        captureOpts,
+				Compare:        compare.compareOptions(),
+				ResultSelector: startFlags.resultSelector,
+				NoClipboard:    noClipboard,
+				Formats:        formats,
+				HideNamespaces: hideNs,
+				ViewNamespace:  viewNs,
+				MaxPerKind:     maxPerKind,
+				Focus:          focus,
+				Verbose:        verbose,
+			})
 			p := tea.NewProgram(model, tea.WithAltScreen())
 			
-			if _, err := p.Run(); err != nil {
+			finalModel, err := p.Run()
+			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error running application: %v\n", err)
-				os.Exit(1)
+				os.Exit(exitError)
+			}
+
+			// The altscreen is gone now, so the profile can go to the terminal
+			if startFlags.profile {
+				if m, ok := finalModel.(tui.Model); ok {
+					baseline, current := m.Snapshots()
+					printProfile(os.Stdout, "baseline", baseline)
+					printProfile(os.Stdout, "current", current)
+				}
+			}
+
+			// Save whichever snapshots were captured before quitting
+			if snapshotDir != "" {
+				if m, ok := finalModel.(tui.Model); ok {
+					baseline, current := m.Snapshots()
+					labels := []string{"baseline", "current"}
+					for i, snap := range []*snapshot.Snapshot{baseline, current} {
+						if snap == nil {
+							continue
+						}
+						if err := saveSnapshot(os.Stdout, snapshotDir, labels[i], note, snap); err != nil {
+							fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+							os.Exit(exitError)
+						}
+					}
+				}
 			}
 		},
 	}
 
 	// Add flags to start command
-	startCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Kubernetes namespace to monitor (empty for all namespaces)")
-	startCmd.Flags().StringVarP(&ignorePattern, "ignore", "i", "", "Regex pattern to ignore additional resource kinds")
-	startCmd.Flags().StringVarP(&kubeconfigPath, "kubeconfig", "k", "", "Path to kubeconfig file")
-	startCmd.Flags().BoolVarP(&useDefaultExclusions, "exclude-noisy", "e", true, "Exclude noisy resources like Events, Endpoints, etc.")
-	startCmd.Flags().BoolVarP(&includeSystemNamespaces, "include-system", "s", false, "Include system namespaces (kube-system, etc.)")
+	addCaptureFlags(startCmd, &startFlags)
+	addCompareFlags(startCmd, &compare)
+	startCmd.Flags().BoolVar(&headless, "headless", false, "Use a plain prompt instead of the interactive TUI (same as the 'run' command)")
+	startCmd.Flags().StringSliceVar(&formats, "formats", tui.DefaultFormats(), "Views the tab key cycles through, in order (e.g. table,yaml)")
+	startCmd.Flags().StringSliceVar(&hideNs, "hide-namespace", nil, "Hide resources in these namespaces from the diff view (they are still captured; 'h' hides more)")
+	startCmd.Flags().StringVar(&viewNs, "view-namespace", "", "Only show the changes in this namespace of an --all-namespaces capture ('f' switches to the selected row's namespace and back)")
+	startCmd.Flags().IntVar(&maxPerKind, "max-per-kind", 0, "Show at most this many changes of each kind, followed by \"… and N more\" (0 for no limit)")
+	startCmd.Flags().StringVar(&focus, "focus", "", "Open the details of this resource as soon as the diff is ready, as KIND or KIND/NAME (e.g. Deployment/web)")
+	startCmd.Flags().BoolVar(&verbose, "verbose", false, "Press 'v' in the diff view to list the resource types that weren't captured and why")
+	startCmd.Flags().StringVar(&snapshotDir, "snapshot-dir", "", "Save the baseline and current snapshots into this directory when done")
+	startCmd.Flags().StringVar(&note, "note", "", "Free-text note saved in the --snapshot-dir snapshots and shown when they're compared, e.g. \"deployed v2.3\"")
+	startCmd.Flags().BoolVar(&noClipboard, "no-clipboard", false, "Save copied YAML to a temp file instead of the clipboard (auto-enabled over SSH or without a display)")
 
 	// List resources command
 	listCmd := &cobra.Command{
@@ -88,17 +241,42 @@ func main() {
 				fmt.Printf("  %s\n", cleanPattern)
 			}
 			
-			fmt.Println("\nSystem namespaces (excluded with --include-system=false):")
-			fmt.Println("-----------------------------------------------------")
+			fmt.Println("\nAnnotations ignored when detecting modifications:")
+			fmt.Println("------------------------------------------------")
+			for _, annotation := range filter.DefaultIgnoredAnnotations() {
+				fmt.Printf("  %s\n", annotation)
+			}
+
+			fmt.Println("\nSystem namespaces (excluded unless --include-system is set):")
+			fmt.Println("------------------------------------------------------------")
 			for _, ns := range filter.CommonSystemNamespaces() {
 				fmt.Printf("  %s\n", ns)
 			}
 		},
 	}
 
+	// Version command
+	versionCmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print version and build information",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Print(versionInfo())
+		},
+	}
+
 	// Add commands to root
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(newRunCmd())
+	rootCmd.AddCommand(newCompareCmd())
+	rootCmd.AddCommand(newMigrateCmd())
+	rootCmd.AddCommand(newValidateCmd())
+	rootCmd.AddCommand(newStatsCmd())
+	rootCmd.AddCommand(newWatchCmd())
+	rootCmd.AddCommand(newDriftCmd())
+	rootCmd.AddCommand(newImagesCmd())
+	rootCmd.AddCommand(newBenchCmd())
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
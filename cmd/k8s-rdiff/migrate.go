@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// newMigrateCmd creates the `migrate` command, which rewrites a saved snapshot in the current schema
+func newMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate <snapshot.json> [output.json]",
+		Short: "Rewrite a saved snapshot in the current schema version",
+		Long: "Loads a snapshot saved by an older k8s-rdiff release and writes it in the current\n" +
+			"schema version. Fields the old snapshot lacks are left empty. Without an output\n" +
+			"path the snapshot is rewritten in place.",
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			input := args[0]
+			output := input
+			if len(args) == 2 {
+				output = args[1]
+			}
+
+			data, err := ioutil.ReadFile(input)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading snapshot: %v\n", err)
+				os.Exit(1)
+			}
+
+			fromVersion, err := snapshot.ReadSchemaVersion(data)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading snapshot %s: %v\n", input, err)
+				os.Exit(1)
+			}
+
+			snap, err := snapshot.Decode(data)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading snapshot %s: %v\n", input, err)
+				os.Exit(1)
+			}
+
+			if err := snap.WriteFile(output); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing snapshot: %v\n", err)
+				os.Exit(1)
+			}
+
+			if fromVersion == snapshot.SchemaVersion {
+				fmt.Printf("%s is already at schema version %d, wrote %s\n", input, snapshot.SchemaVersion, output)
+			} else {
+				fmt.Printf("Migrated %s from schema version %d to %d, wrote %s\n", input, fromVersion, snapshot.SchemaVersion, output)
+			}
+		},
+	}
+
+	return cmd
+}
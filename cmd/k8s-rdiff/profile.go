@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// profileTopN is how many of the slowest resource types --profile reports
+const profileTopN = 10
+
+// printProfile writes the slowest resource types of a capture, to guide --ignore tuning
+func printProfile(w io.Writer, label string, snap *snapshot.Snapshot) {
+	if snap == nil || len(snap.Timings) == 0 {
+		return
+	}
+
+	var total time.Duration
+	for _, timing := range snap.Timings {
+		total += timing.Duration
+	}
+
+	fmt.Fprintf(w, "\nSlowest resource types (%s capture, %d types listed in %s):\n",
+		label, len(snap.Timings), total.Round(time.Millisecond))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "  RESOURCE TYPE\tDURATION\tCOUNT")
+	for _, timing := range snap.SlowestTypes(profileTopN) {
+		fmt.Fprintf(tw, "  %s\t%s\t%d\n", timing.ResourceType, timing.Duration.Round(time.Millisecond), timing.Count)
+	}
+	tw.Flush()
+}
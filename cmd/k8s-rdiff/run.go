@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// newRunCmd creates the `run` command, the non-interactive counterpart of `start`
+func newRunCmd() *cobra.Command {
+	var (
+		flags    captureFlags
+		compare  compareFlags
+		output   outputFlags
+		headless headlessOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Capture, wait for 'continue', capture again and print the diff without the TUI",
+		Long: "Runs the same capture/continue/capture flow as 'start' using a plain prompt instead of\n" +
+			"the interactive TUI. Useful on dumb terminals or over SSH where the altscreen misbehaves.\n" +
+			"With --exec, a command is run between the captures instead, for scripted workflows.\n\n" +
+			"Progress messages go to stderr so the diff on stdout can be piped. Exits with 0 when\n" +
+			"no changes were detected, 2 when changes were detected and 3 on error.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := output.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			if err := compare.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+
+			if err := flags.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			selector, _ := flags.selector()
+
+			captureOpts := flags.captureOptions(os.Stderr)
+			compareOpts := compare.compareOptions()
+			compareOpts.CollectUnchanged = output.showUnchanged
+			os.Exit(runHeadless(captureOpts, compareOpts, selector, output, headless))
+		},
+	}
+
+	addCaptureFlags(cmd, &flags)
+	addCompareFlags(cmd, &compare)
+	addOutputFlags(cmd, &output)
+	cmd.Flags().StringVar(&headless.outputDir, "output-dir", "", "Also write one <op>-<kind>-<ns>-<name>.yaml.diff file per changed resource into this directory")
+	cmd.Flags().StringVar(&headless.snapshotDir, "snapshot-dir", "", "Save the baseline and current snapshots into this directory, e.g. to archive them as CI artifacts")
+	cmd.Flags().StringVar(&headless.note, "note", "", "Free-text note saved in the --snapshot-dir snapshots and shown when they're compared, e.g. \"deployed v2.3\"")
+	cmd.Flags().StringVar(&headless.exec, "exec", "", "Run this shell command between the two captures instead of waiting for 'continue'; its output goes to stderr")
+	cmd.Flags().BoolVar(&headless.execIgnoreError, "exec-ignore-error", false, "Print the diff even if the --exec command exits non-zero")
+	cmd.Flags().BoolVar(&headless.bench, "bench", false, "Print how long each capture and the diff took, e.g. to include in a slowness report")
+
+	return cmd
+}
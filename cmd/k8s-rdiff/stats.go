@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// newStatsCmd creates the `stats` command, which captures a single snapshot and
+// prints how many resources there are per kind and per namespace
+func newStatsCmd() *cobra.Command {
+	var flags captureFlags
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Print a count of resources per kind and per namespace",
+		Long: "Captures one snapshot with the same filters as 'start' and prints the number of\n" +
+			"resources per resource type and per namespace, largest first. Useful as a cluster\n" +
+			"inventory and for deciding what to exclude before diffing.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := flags.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			captureOpts := flags.captureOptions(os.Stderr)
+			fmt.Fprint(os.Stderr, "Capturing snapshot... ")
+			snap, err := snapshot.Capture(context.Background(), captureOpts)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "failed!")
+				fmt.Fprintf(os.Stderr, "Error capturing snapshot: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintln(os.Stderr, "done!")
+			printProfile(os.Stderr, "stats", snap)
+			if len(snap.FailedTypes) > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: counts are incomplete, these types failed to list: %s\n",
+					strings.Join(snap.FailedTypes, ", "))
+			}
+
+			printStats(os.Stdout, snap)
+		},
+	}
+
+	addCaptureFlags(cmd, &flags)
+
+	return cmd
+}
+
+// printStats writes the per-kind and per-namespace resource counts of a snapshot
+func printStats(w io.Writer, snap *snapshot.Snapshot) {
+	fmt.Fprintf(w, "Total: %d resources\n\n", len(snap.Resources))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "RESOURCE TYPE\tCOUNT")
+	for _, count := range snap.CountByKind() {
+		fmt.Fprintf(tw, "%s\t%d\n", count.Name, count.Count)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+
+	tw = tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "NAMESPACE\tCOUNT")
+	for _, count := range snap.CountByNamespace() {
+		name := count.Name
+		if name == "" {
+			name = "(cluster-scoped)"
+		}
+		fmt.Fprintf(tw, "%s\t%d\n", name, count.Count)
+	}
+	tw.Flush()
+}
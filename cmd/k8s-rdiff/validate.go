@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// exitInvalid is the exit code of `validate` when a snapshot loads but has problems
+const exitInvalid = 2
+
+// newValidateCmd creates the `validate` command, which checks a saved snapshot
+// before it's fed into other tooling
+func newValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate <snapshot.json>",
+		Short: "Check that a saved snapshot is complete and consistent",
+		Long: "Loads a saved snapshot as 'compare' does and checks its structure: the schema\n" +
+			"version, that every resource key is unique and matches the resource, and that\n" +
+			"required fields are set. Prints the resource counts if the snapshot is valid.\n\n" +
+			"Exits with 0 if the snapshot is valid, 2 if it has problems and 3 if it can't\n" +
+			"be read at all (e.g. a truncated file).",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := args[0]
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading snapshot: %v\n", err)
+				os.Exit(exitError)
+			}
+
+			version, err := snapshot.ReadSchemaVersion(data)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: snapshot %s: %v\n", path, err)
+				os.Exit(exitError)
+			}
+
+			snap, problems, err := snapshot.Validate(data)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: snapshot %s: %v\n", path, err)
+				os.Exit(exitError)
+			}
+
+			if len(problems) > 0 {
+				fmt.Fprintf(os.Stderr, "%s has %d problem(s):\n", path, len(problems))
+				for _, problem := range problems {
+					fmt.Fprintf(os.Stderr, "  %s\n", problem)
+				}
+				os.Exit(exitInvalid)
+			}
+
+			if version < snapshot.SchemaVersion {
+				fmt.Printf("%s is valid (schema version %d, migrated to %d when loaded)\n", path, version, snapshot.SchemaVersion)
+			} else {
+				fmt.Printf("%s is valid (schema version %d)\n", path, version)
+			}
+			if snap.Note != "" {
+				fmt.Printf("Note: %s\n", snap.Note)
+			}
+			if snap.Partial {
+				fmt.Println("The snapshot is partial: not every resource type was captured")
+			}
+			fmt.Println()
+			printStats(os.Stdout, snap)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// watchSettleDelay lets a burst of informer events (e.g. a rollout) settle into one diff
+const watchSettleDelay = time.Second
+
+// newWatchCmd creates the `watch` command, which keeps diffing the cluster against
+// its previous state and prints each change as it happens
+func newWatchCmd() *cobra.Command {
+	var (
+		flags    captureFlags
+		compare  compareFlags
+		interval time.Duration
+		informer bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Continuously diff the cluster and print changes as they happen",
+		Long: "Captures a snapshot, then captures again every --interval and prints what changed\n" +
+			"since the previous capture, until interrupted. With --informer, the resources are\n" +
+			"listed once and kept up to date through watches instead, and a diff is printed as\n" +
+			"soon as something changes; this is much cheaper on large clusters.\n\n" +
+			"Exits with 0 if no changes were seen and 2 if some were.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := flags.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			if err := compare.validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			if interval <= 0 {
+				fmt.Fprintf(os.Stderr, "Error: invalid interval %s: must be greater than 0\n", interval)
+				os.Exit(exitError)
+			}
+			if informer && flags.fieldSelector != "" {
+				fmt.Fprintln(os.Stderr, "Error: --field-selector can't be combined with --informer")
+				os.Exit(exitError)
+			}
+			selector, _ := flags.selector()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			captureOpts := flags.captureOptions(os.Stderr)
+			os.Exit(runWatch(ctx, captureOpts, compare.compareOptions(), selector, interval, informer))
+		},
+	}
+
+	addCaptureFlags(cmd, &flags)
+	addCompareFlags(cmd, &compare)
+	cmd.Flags().DurationVar(&interval, "interval", 30*time.Second, "How often to capture and diff again (without --informer)")
+	cmd.Flags().BoolVar(&informer, "informer", false, "Keep resources up to date with informer watches instead of relisting every interval")
+
+	return cmd
+}
+
+// runWatch diffs each new capture against the previous one until ctx is done and
+// returns the process exit code
+func runWatch(ctx context.Context, captureOpts snapshot.CaptureOptions, compareOpts diff.CompareOptions, selector labels.Selector, interval time.Duration, informer bool) int {
+	msgs := os.Stderr
+
+	// take captures the current state; next waits until it's time to capture again
+	take := func() (*snapshot.Snapshot, error) {
+		return snapshot.Capture(ctx, captureOpts)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	next := func() bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	}
+
+	if informer {
+		fmt.Fprint(msgs, "Listing and watching resources... ")
+		watcher, err := snapshot.NewWatcher(ctx, captureOpts)
+		if err != nil {
+			fmt.Fprintln(msgs, "failed!")
+			if errors.Is(err, snapshot.ErrCaptureCancelled) {
+				return exitNoChanges
+			}
+			fmt.Fprintf(os.Stderr, "Error starting watch: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintln(msgs, "done!")
+
+		take = func() (*snapshot.Snapshot, error) {
+			return watcher.Snapshot(ctx)
+		}
+		next = func() bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-watcher.Changes():
+			}
+
+			select {
+			case <-ctx.Done():
+				return false
+			case <-time.After(watchSettleDelay):
+				return true
+			}
+		}
+	}
+
+	previous, err := take()
+	if err != nil {
+		if errors.Is(err, snapshot.ErrCaptureCancelled) {
+			return exitNoChanges
+		}
+		fmt.Fprintf(os.Stderr, "Error capturing snapshot: %v\n", err)
+		return exitError
+	}
+	fmt.Fprintf(msgs, "Watching %d resources, press Ctrl+C to stop\n", len(previous.Resources))
+
+	exitCode := exitNoChanges
+	for next() {
+		current, err := take()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			// Keep watching; the next capture may well succeed
+			fmt.Fprintf(os.Stderr, "Warning: capture failed, retrying: %v\n", err)
+			continue
+		}
+
+		result := diff.CompareWithOptions(previous, current, compareOpts).FilterByLabels(selector)
+		previous = current
+		if result.IsEmpty() {
+			continue
+		}
+
+		exitCode = exitChanges
+		if len(compareOpts.WatchFields) > 0 {
+			printFieldChanges(os.Stdout, current.Timestamp, result, compareOpts.WatchFields)
+			continue
+		}
+		fmt.Printf("\n=== Changes at %s ===\n", current.Timestamp.Local().Format(time.RFC3339))
+		diff.OutputTable(result, os.Stdout)
+	}
+
+	return exitCode
+}
+
+// printFieldChanges prints one compact line per changed resource, showing only
+// how the watched fields changed, e.g.
+//
+//	10:02:17 apps/v1/Deployment payments/web status.readyReplicas: 1 → 3
+func printFieldChanges(w io.Writer, at time.Time, result *diff.DiffResult, paths []diff.FieldPath) {
+	timestamp := at.Local().Format("15:04:05")
+	resourceName := func(res diff.ResourceDiff) string {
+		if res.Resource.Namespace == "" {
+			return res.Resource.GroupVersionKind + " " + res.Resource.Name
+		}
+		return res.Resource.GroupVersionKind + " " + res.Resource.Namespace + "/" + res.Resource.Name
+	}
+
+	for _, res := range result.Added {
+		fmt.Fprintf(w, "%s %s added\n", timestamp, resourceName(res))
+	}
+	for _, res := range result.Removed {
+		fmt.Fprintf(w, "%s %s removed\n", timestamp, resourceName(res))
+	}
+	for _, res := range result.Modified {
+		changes := []string{}
+		for _, change := range res.FieldChanges(paths) {
+			changes = append(changes, fmt.Sprintf("%s: %s → %s", change.Path, change.OldValue, change.NewValue))
+		}
+		fmt.Fprintf(w, "%s %s %s\n", timestamp, resourceName(res), strings.Join(changes, ", "))
+	}
+}
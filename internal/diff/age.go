@@ -0,0 +1,41 @@
+package diff
+
+import (
+	"fmt"
+	"time"
+)
+
+// FormatAge renders a duration the way kubectl shows ages (45s, 12m, 2h12m, 3d4h)
+func FormatAge(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		hours, minutes := int(d.Hours()), int(d.Minutes())%60
+		if minutes == 0 {
+			return fmt.Sprintf("%dh", hours)
+		}
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	default:
+		days, hours := int(d.Hours())/24, int(d.Hours())%24
+		if days >= 8 || hours == 0 {
+			return fmt.Sprintf("%dd", days)
+		}
+		return fmt.Sprintf("%dd%dh", days, hours)
+	}
+}
+
+// humanizeAge returns how long ago t was, e.g. "3d" or "2h12m". Snapshots
+// taken before creation timestamps were recorded show "<unknown>", as in kubectl.
+func humanizeAge(t time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return FormatAge(time.Since(t))
+}
@@ -0,0 +1,20 @@
+package diff
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+func BenchmarkCompare(b *testing.B) {
+	for _, size := range []int{1000, 10000, 50000} {
+		baseline, current := snapshot.SyntheticPair(size, 0.1)
+		b.Run(fmt.Sprintf("resources=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Compare(baseline, current)
+			}
+		})
+	}
+}
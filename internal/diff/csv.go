@@ -0,0 +1,43 @@
+package diff
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+)
+
+// OutputCSV outputs the diff as CSV with one row per changed resource. Added
+// resources only have the new version and hash, removed ones only the old.
+func OutputCSV(diff *DiffResult, writer io.Writer) {
+	w := csv.NewWriter(writer)
+	w.Write([]string{"operation", "gvk", "namespace", "name", "oldVersion", "newVersion", "oldHash", "newHash"})
+
+	row := func(res ResourceDiff, oldVersion, newVersion, oldHash, newHash string) {
+		w.Write([]string{
+			string(res.Type),
+			res.Resource.GroupVersionKind,
+			res.Resource.Namespace,
+			res.Resource.Name,
+			oldVersion,
+			newVersion,
+			oldHash,
+			newHash,
+		})
+	}
+
+	for _, res := range diff.Added {
+		row(res, "", res.Resource.ResourceVersion, "", res.Resource.SpecHash)
+	}
+	for _, res := range diff.Removed {
+		row(res, res.Resource.ResourceVersion, "", res.Resource.SpecHash, "")
+	}
+	for _, res := range diff.Modified {
+		row(res, res.OldResourceVersion, res.NewResourceVersion, res.OldSpecHash, res.NewSpecHash)
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+	}
+}
@@ -0,0 +1,78 @@
+package diff
+
+import (
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DataKeyChange is a key of a ConfigMap or Secret whose value was added, removed
+// or changed between the baseline and the current state
+type DataKeyChange struct {
+	Key  string   // Key in data or binaryData
+	Type DiffType // Added, Removed or Modified
+}
+
+// DataKeyChanges returns the changed data and binaryData keys of a modified
+// resource, sorted by key. It is empty for resources without data, and when the
+// manifests are missing or can't be parsed.
+func (r ResourceDiff) DataKeyChanges() []DataKeyChange {
+	if r.BaselineResource == nil || r.CurrentResource == nil {
+		return nil
+	}
+
+	base, ok := manifestData(r.BaselineResource.Manifest)
+	if !ok {
+		return nil
+	}
+	current, ok := manifestData(r.CurrentResource.Manifest)
+	if !ok {
+		return nil
+	}
+
+	var changes []DataKeyChange
+	for key, value := range current {
+		baseValue, found := base[key]
+		switch {
+		case !found:
+			changes = append(changes, DataKeyChange{Key: key, Type: Added})
+		case !reflect.DeepEqual(baseValue, value):
+			changes = append(changes, DataKeyChange{Key: key, Type: Modified})
+		}
+	}
+	for key := range base {
+		if _, found := current[key]; !found {
+			changes = append(changes, DataKeyChange{Key: key, Type: Removed})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// manifestData returns the data and binaryData entries of a YAML manifest by key
+func manifestData(manifest string) (map[string]interface{}, bool) {
+	if manifest == "" {
+		return nil, false
+	}
+
+	var obj struct {
+		Data       map[string]interface{} `yaml:"data"`
+		BinaryData map[string]interface{} `yaml:"binaryData"`
+	}
+	if err := yaml.Unmarshal([]byte(manifest), &obj); err != nil {
+		return nil, false
+	}
+
+	entries := map[string]interface{}{}
+	for key, value := range obj.Data {
+		entries[key] = value
+	}
+	for key, value := range obj.BinaryData {
+		entries[key] = value
+	}
+	return entries, true
+}
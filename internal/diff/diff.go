@@ -5,12 +5,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/fatih/color"
+	"github.com/winson-sou/k8s-rdiff/internal/filter"
 	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
 	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // DiffType represents the type of difference
@@ -20,6 +24,9 @@ const (
 	Added    DiffType = "Added"
 	Removed  DiffType = "Removed"
 	Modified DiffType = "Modified"
+
+	// Unchanged resources are only collected with CompareOptions.CollectUnchanged
+	Unchanged DiffType = "Unchanged"
 )
 
 // ResourceDiff represents a difference in a resource
@@ -30,6 +37,10 @@ type ResourceDiff struct {
 	NewResourceVersion string                 `json:"newResourceVersion,omitempty"`
 	OldSpecHash       string                  `json:"oldSpecHash,omitempty"`
 	NewSpecHash       string                  `json:"newSpecHash,omitempty"`
+	OldGeneration     int64                   `json:"oldGeneration,omitempty"`
+	NewGeneration     int64                   `json:"newGeneration,omitempty"`
+	Severity          Severity                `json:"severity"`
+	ImmutableFields   []string                `json:"immutableFields,omitempty"` // Changed fields that can't be updated in place
 	BaselineResource  *snapshot.ResourceInfo  `json:"-"` // Not included in JSON/YAML output
 	CurrentResource   *snapshot.ResourceInfo  `json:"-"` // Not included in JSON/YAML output
 }
@@ -44,11 +55,30 @@ func (r *ResourceDiff) IsPresentInCurrent() bool {
 	return r.Type == Modified || r.Type == Added
 }
 
+// GenerationChanged reports whether the API server bumped metadata.generation of a
+// modified resource, which it does on spec changes only. known is false when either
+// snapshot has no generation for it (kinds without one, or older snapshots).
+func (r *ResourceDiff) GenerationChanged() (changed, known bool) {
+	if r.Type != Modified || r.OldGeneration == 0 || r.NewGeneration == 0 {
+		return false, false
+	}
+	return r.OldGeneration != r.NewGeneration, true
+}
+
 // DiffResult contains all differences between snapshots
 type DiffResult struct {
 	Added    []ResourceDiff `json:"added"`
 	Removed  []ResourceDiff `json:"removed"`
 	Modified []ResourceDiff `json:"modified"`
+	Warnings []string       `json:"warnings,omitempty"` // Reasons the comparison may be misleading
+
+	// Truncated counts, by resource type (GVK), the changes left out by LimitPerKind
+	Truncated map[string]int `json:"truncated,omitempty" yaml:"truncated,omitempty"`
+
+	// Unchanged are the resources in both snapshots that weren't considered
+	// modified, with both hashes. Only set with CompareOptions.CollectUnchanged, to
+	// debug changes that weren't detected; they aren't part of any output format.
+	Unchanged []ResourceDiff `json:"-" yaml:"-"`
 }
 
 // IsEmpty checks if there are any differences
@@ -56,25 +86,411 @@ func (d *DiffResult) IsEmpty() bool {
 	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
 }
 
+// FilterByLabels returns a new DiffResult containing only the resources whose
+// labels match the selector. Removed resources are matched on their baseline labels.
+func (d *DiffResult) FilterByLabels(selector labels.Selector) *DiffResult {
+	if selector == nil || selector.Empty() {
+		return d
+	}
+
+	match := func(diffs []ResourceDiff) []ResourceDiff {
+		filtered := []ResourceDiff{}
+		for _, res := range diffs {
+			if selector.Matches(labels.Set(res.Resource.Labels)) {
+				filtered = append(filtered, res)
+			}
+		}
+		return filtered
+	}
+
+	return &DiffResult{
+		Added:     match(d.Added),
+		Removed:   match(d.Removed),
+		Modified:  match(d.Modified),
+		Warnings:  d.Warnings,
+		Unchanged: match(d.Unchanged),
+	}
+}
+
+// ExcludeKinds returns a new DiffResult without the resources of the given
+// resource types (GVKs)
+func (d *DiffResult) ExcludeKinds(kinds []string) *DiffResult {
+	if len(kinds) == 0 {
+		return d
+	}
+
+	excluded := make(map[string]bool, len(kinds))
+	for _, kind := range kinds {
+		excluded[kind] = true
+	}
+
+	keep := func(diffs []ResourceDiff) []ResourceDiff {
+		filtered := []ResourceDiff{}
+		for _, res := range diffs {
+			if !excluded[res.Resource.GroupVersionKind] {
+				filtered = append(filtered, res)
+			}
+		}
+		return filtered
+	}
+
+	return &DiffResult{
+		Added:    keep(d.Added),
+		Removed:  keep(d.Removed),
+		Modified: keep(d.Modified),
+		Warnings: d.Warnings,
+	}
+}
+
+// FilterOptions selects the changes kept by DiffResult.Filter. Every dimension
+// that's set must match; an empty one matches everything.
+type FilterOptions struct {
+	Operations  []DiffType     // Types of change to keep
+	Kinds       []string       // Kind names (e.g. Deployment, matched case-insensitively) or full resource types (e.g. apps/v1/Deployment)
+	Namespaces  []string       // Namespaces to keep; "" keeps cluster-scoped resources
+	NamePattern *regexp.Regexp // Resource names to keep
+}
+
+// IsEmpty reports whether the options keep every change
+func (o FilterOptions) IsEmpty() bool {
+	return len(o.Operations) == 0 && len(o.Kinds) == 0 && len(o.Namespaces) == 0 && o.NamePattern == nil
+}
+
+// matches reports whether a change is kept by the options
+func (o FilterOptions) matches(res ResourceDiff) bool {
+	if len(o.Operations) > 0 && !containsDiffType(o.Operations, res.Type) {
+		return false
+	}
+
+	if len(o.Kinds) > 0 {
+		gvk := res.Resource.GroupVersionKind
+		kind := gvk[strings.LastIndex(gvk, "/")+1:]
+		matched := false
+		for _, k := range o.Kinds {
+			if k == gvk || strings.EqualFold(k, kind) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(o.Namespaces) > 0 {
+		matched := false
+		for _, namespace := range o.Namespaces {
+			if namespace == res.Resource.Namespace {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return o.NamePattern == nil || o.NamePattern.MatchString(res.Resource.Name)
+}
+
+// containsDiffType reports whether op is in ops
+func containsDiffType(ops []DiffType, op DiffType) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns a new DiffResult with only the changes the options keep. The
+// CLI output filters and the TUI's operation filters both go through it.
+func (d *DiffResult) Filter(opts FilterOptions) *DiffResult {
+	if opts.IsEmpty() {
+		return d
+	}
+
+	keep := func(diffs []ResourceDiff) []ResourceDiff {
+		filtered := []ResourceDiff{}
+		for _, res := range diffs {
+			if opts.matches(res) {
+				filtered = append(filtered, res)
+			}
+		}
+		return filtered
+	}
+
+	// Unchanged resources have no type of change to filter on
+	unchangedOpts := opts
+	unchangedOpts.Operations = nil
+	var unchanged []ResourceDiff
+	for _, res := range d.Unchanged {
+		if unchangedOpts.matches(res) {
+			unchanged = append(unchanged, res)
+		}
+	}
+
+	return &DiffResult{
+		Added:     keep(d.Added),
+		Removed:   keep(d.Removed),
+		Modified:  keep(d.Modified),
+		Warnings:  d.Warnings,
+		Unchanged: unchanged,
+	}
+}
+
+// ParseDiffType parses a type of change: added, removed or modified
+func ParseDiffType(name string) (DiffType, error) {
+	for _, op := range []DiffType{Added, Removed, Modified} {
+		if strings.EqualFold(name, string(op)) {
+			return op, nil
+		}
+	}
+	return "", fmt.Errorf("unknown type of change %q (use added, removed or modified)", name)
+}
+
+// LimitPerKind returns a new DiffResult with at most max changes of each resource
+// type (GVK), keeping the first ones in added, removed, modified order. The number
+// of changes left out is recorded in Truncated. A max of 0 or less means no limit.
+func (d *DiffResult) LimitPerKind(max int) *DiffResult {
+	if max <= 0 {
+		return d
+	}
+
+	seen := map[string]int{}
+	truncated := map[string]int{}
+	keep := func(diffs []ResourceDiff) []ResourceDiff {
+		limited := []ResourceDiff{}
+		for _, res := range diffs {
+			kind := res.Resource.GroupVersionKind
+			if seen[kind]++; seen[kind] > max {
+				truncated[kind]++
+				continue
+			}
+			limited = append(limited, res)
+		}
+		return limited
+	}
+
+	limited := &DiffResult{
+		Added:    keep(d.Added),
+		Removed:  keep(d.Removed),
+		Modified: keep(d.Modified),
+		Warnings: d.Warnings,
+	}
+	if len(truncated) > 0 {
+		limited.Truncated = truncated
+	}
+	return limited
+}
+
+// TruncatedKinds returns the resource types with changes left out by
+// LimitPerKind, sorted
+func (d *DiffResult) TruncatedKinds() []string {
+	kinds := make([]string, 0, len(d.Truncated))
+	for kind := range d.Truncated {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// ExcludeNamespaces returns a new DiffResult without the resources in the given
+// namespaces. Cluster-scoped resources are always kept.
+func (d *DiffResult) ExcludeNamespaces(namespaces []string) *DiffResult {
+	if len(namespaces) == 0 {
+		return d
+	}
+
+	excluded := make(map[string]bool, len(namespaces))
+	for _, namespace := range namespaces {
+		excluded[namespace] = true
+	}
+
+	keep := func(diffs []ResourceDiff) []ResourceDiff {
+		filtered := []ResourceDiff{}
+		for _, res := range diffs {
+			if res.Resource.Namespace == "" || !excluded[res.Resource.Namespace] {
+				filtered = append(filtered, res)
+			}
+		}
+		return filtered
+	}
+
+	return &DiffResult{
+		Added:     keep(d.Added),
+		Removed:   keep(d.Removed),
+		Modified:  keep(d.Modified),
+		Warnings:  d.Warnings,
+		Unchanged: keep(d.Unchanged),
+	}
+}
+
+// CompatibilityWarnings explains why two snapshots may not be comparable, e.g.
+// because they were captured with different filters or namespaces
+func CompatibilityWarnings(baseline, current *snapshot.Snapshot) []string {
+	var warnings []string
+
+	if baseline.Namespace != current.Namespace {
+		warnings = append(warnings, fmt.Sprintf(
+			"snapshots cover different namespaces (%q vs %q)", baseline.Namespace, current.Namespace))
+	}
+
+	// Snapshots saved before filters were recorded have no hash to compare
+	if baseline.FilterHash != "" && current.FilterHash != "" && baseline.FilterHash != current.FilterHash {
+		warnings = append(warnings,
+			"snapshots were captured with different filters (e.g. --exclude-noisy or --ignore); resources may show as added or removed only because of the filters")
+	}
+
+	return warnings
+}
+
+// CompareOptions controls what counts as a modification
+type CompareOptions struct {
+	// IgnoreResourceVersion only compares spec hashes and metadata, so status
+	// updates and other benign resourceVersion bumps aren't reported as modifications
+	IgnoreResourceVersion bool
+
+	// TrackGeneration also marks a resource modified when its metadata.generation
+	// changed, so a spec changed and then reverted still shows up (as churn) even
+	// though its hash is unchanged. Resources without a generation are unaffected.
+	TrackGeneration bool
+
+	// IgnoreAnnotations are annotation keys whose changes never mark a resource
+	// modified (see filter.DefaultIgnoredAnnotations)
+	IgnoreAnnotations []string
+
+	// IgnoreAnnotationsRegex, when set, also ignores the annotations whose keys
+	// match it, e.g. every *.fluxcd.io/ annotation
+	IgnoreAnnotationsRegex *regexp.Regexp
+
+	// Severities override DefaultSeverities, by kind name (e.g. Secret) or full
+	// resource type (e.g. apps/v1/Deployment)
+	Severities map[string]Severity
+
+	// WatchFields, when set, limit what counts as a modification to changes of
+	// these manifest fields; everything else about the resource is ignored
+	WatchFields []FieldPath
+
+	// ImmutableFields are fields that can't change after creation, by kind name or
+	// full resource type, in addition to DefaultImmutableFields
+	ImmutableFields map[string][]FieldPath
+
+	// CollectUnchanged also records the resources considered unchanged in
+	// DiffResult.Unchanged, to debug the hashing and comparison
+	CollectUnchanged bool
+}
+
 // Compare compares two snapshots and returns the differences
 func Compare(baseline, current *snapshot.Snapshot) *DiffResult {
+	return CompareWithOptions(baseline, current, CompareOptions{
+		IgnoreAnnotations: filter.DefaultIgnoredAnnotations(),
+	})
+}
+
+// isModified reports whether a resource present in both snapshots changed
+func (o CompareOptions) isModified(baseRes, res snapshot.ResourceInfo) bool {
+	if len(o.WatchFields) > 0 {
+		// Without both manifests the watched fields can't be compared, so fall
+		// back to the regular checks rather than missing a change
+		if changed, ok := watchedFieldsChanged(o.WatchFields, baseRes.Manifest, res.Manifest); ok {
+			return changed
+		}
+	}
+
+	if res.SpecHash != baseRes.SpecHash || o.metadataChanged(baseRes, res) {
+		return true
+	}
+	if o.TrackGeneration && baseRes.Generation != 0 && res.Generation != 0 && baseRes.Generation != res.Generation {
+		return true
+	}
+	if o.IgnoreResourceVersion || res.ResourceVersion == baseRes.ResourceVersion {
+		return false
+	}
+
+	// A resourceVersion bump explained by an ignored annotation changing (e.g. a
+	// GitOps tool re-stamping its tracking id) isn't a modification
+	return !stringMapsDiffer(baseRes.Annotations, res.Annotations, nil)
+}
+
+// metadataChanged reports whether the labels or any non-ignored annotations changed
+func (o CompareOptions) metadataChanged(baseRes, res snapshot.ResourceInfo) bool {
+	return stringMapsDiffer(baseRes.Labels, res.Labels, nil) ||
+		stringMapsDiffer(baseRes.Annotations, res.Annotations, o.ignoresAnnotation())
+}
+
+// ignoresAnnotation returns whether changes to an annotation are ignored, by key
+func (o CompareOptions) ignoresAnnotation() func(key string) bool {
+	ignored := make(map[string]bool, len(o.IgnoreAnnotations))
+	for _, key := range o.IgnoreAnnotations {
+		ignored[key] = true
+	}
+
+	return func(key string) bool {
+		return ignored[key] || (o.IgnoreAnnotationsRegex != nil && o.IgnoreAnnotationsRegex.MatchString(key))
+	}
+}
+
+// stringMapsDiffer compares two maps, skipping the keys for which skip (if not
+// nil) returns true
+func stringMapsDiffer(a, b map[string]string, skip func(key string) bool) bool {
+	skipped := func(key string) bool { return skip != nil && skip(key) }
+
+	for key, value := range a {
+		if other, ok := b[key]; !skipped(key) && (!ok || other != value) {
+			return true
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !skipped(key) && !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CompareWithOptions compares two snapshots using the given options and returns the differences
+func CompareWithOptions(baseline, current *snapshot.Snapshot, opts CompareOptions) *DiffResult {
 	result := &DiffResult{
 		Added:    []ResourceDiff{},
 		Removed:  []ResourceDiff{},
 		Modified: []ResourceDiff{},
+		Warnings: CompatibilityWarnings(baseline, current),
+	}
+	severities := opts.severities()
+	immutableFields := opts.immutableFields()
+
+	// Resources of types that changed scope are keyed with a namespace in one
+	// snapshot only, so match those by name
+	scopePairs, scopeWarnings := scopeChanges(baseline, current)
+	result.Warnings = append(result.Warnings, scopeWarnings...)
+	pairedBaseline := make(map[string]bool, len(scopePairs))
+	for _, baseKey := range scopePairs {
+		pairedBaseline[baseKey] = true
 	}
 
 	// Find added and modified resources
 	for key, res := range current.Resources {
-		if baseRes, exists := baseline.Resources[key]; !exists {
+		baseRes, exists := baseline.Resources[key]
+		if baseKey, paired := scopePairs[key]; paired && !exists {
+			baseRes, exists = baseline.Resources[baseKey], true
+		}
+
+		if !exists {
+			// The type wasn't listed for the baseline, so we can't tell it was added
+			if baseline.HasUnknownResources(res.GroupVersionKind, res.Namespace) {
+				continue
+			}
+
 			// Resource was added
 			resCopy := res
 			result.Added = append(result.Added, ResourceDiff{
 				Type:            Added,
 				Resource:        res,
 				CurrentResource: &resCopy,
+				Severity:        severityOf(res.GroupVersionKind, severities),
 			})
-		} else if res.ResourceVersion != baseRes.ResourceVersion || res.SpecHash != baseRes.SpecHash {
+		} else if opts.isModified(baseRes, res) {
 			// Resource was modified
 			resCopy := res
 			baseResCopy := baseRes
@@ -85,28 +501,102 @@ func Compare(baseline, current *snapshot.Snapshot) *DiffResult {
 				NewResourceVersion: res.ResourceVersion,
 				OldSpecHash:       baseRes.SpecHash,
 				NewSpecHash:       res.SpecHash,
+				OldGeneration:     baseRes.Generation,
+				NewGeneration:     res.Generation,
 				BaselineResource:  &baseResCopy,
 				CurrentResource:   &resCopy,
+				Severity:          severityOf(res.GroupVersionKind, severities),
+				ImmutableFields:   changedFields(immutableFieldsOf(res.GroupVersionKind, immutableFields), baseRes.Manifest, res.Manifest),
+			})
+		} else if opts.CollectUnchanged {
+			result.Unchanged = append(result.Unchanged, ResourceDiff{
+				Type:               Unchanged,
+				Resource:           res,
+				OldResourceVersion: baseRes.ResourceVersion,
+				NewResourceVersion: res.ResourceVersion,
+				OldSpecHash:        baseRes.SpecHash,
+				NewSpecHash:        res.SpecHash,
+				OldGeneration:      baseRes.Generation,
+				NewGeneration:      res.Generation,
 			})
 		}
 	}
 
 	// Find removed resources
 	for key, res := range baseline.Resources {
-		if _, exists := current.Resources[key]; !exists {
+		if _, exists := current.Resources[key]; !exists && !pairedBaseline[key] {
+			// The type wasn't listed this time, which doesn't mean it was removed
+			if current.HasUnknownResources(res.GroupVersionKind, res.Namespace) {
+				continue
+			}
+
 			// Resource was removed
 			resCopy := res
 			result.Removed = append(result.Removed, ResourceDiff{
 				Type:             Removed,
 				Resource:         res,
 				BaselineResource: &resCopy,
+				Severity:         severityOf(res.GroupVersionKind, severities),
 			})
 		}
 	}
 
+	if len(opts.WatchFields) > 0 {
+		for _, path := range missingWatchedFields(opts.WatchFields, baseline, current) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"watched field %s isn't in any compared manifest, so changes to it can't be detected", path))
+		}
+	}
+
+	// Map iteration order is random, so sort for stable output
+	result.sort()
+
 	return result
 }
 
+// Merge combines several diff results (e.g. captured per namespace) into one sorted result
+func Merge(results ...*DiffResult) *DiffResult {
+	merged := &DiffResult{
+		Added:    []ResourceDiff{},
+		Removed:  []ResourceDiff{},
+		Modified: []ResourceDiff{},
+	}
+
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		merged.Added = append(merged.Added, result.Added...)
+		merged.Removed = append(merged.Removed, result.Removed...)
+		merged.Modified = append(merged.Modified, result.Modified...)
+		merged.Warnings = append(merged.Warnings, result.Warnings...)
+		merged.Unchanged = append(merged.Unchanged, result.Unchanged...)
+	}
+
+	merged.sort()
+
+	return merged
+}
+
+// sort orders each bucket by descending severity, then kind, namespace and name
+func (d *DiffResult) sort() {
+	for _, diffs := range [][]ResourceDiff{d.Added, d.Removed, d.Modified, d.Unchanged} {
+		sort.SliceStable(diffs, func(i, j int) bool {
+			if diffs[i].Severity != diffs[j].Severity {
+				return diffs[i].Severity > diffs[j].Severity
+			}
+			a, b := diffs[i].Resource, diffs[j].Resource
+			if a.GroupVersionKind != b.GroupVersionKind {
+				return a.GroupVersionKind < b.GroupVersionKind
+			}
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.Name < b.Name
+		})
+	}
+}
+
 // DisplayDiff outputs the diff result in the specified format
 func DisplayDiff(diff *DiffResult, format string) {
 	switch strings.ToLower(format) {
@@ -114,13 +604,31 @@ func DisplayDiff(diff *DiffResult, format string) {
 		OutputJSON(diff, os.Stdout)
 	case "yaml":
 		OutputYAML(diff, os.Stdout)
+	case "csv":
+		OutputCSV(diff, os.Stdout)
+	case "junit":
+		OutputJUnit(diff, os.Stdout)
+	case "tree":
+		OutputTree(diff, os.Stdout)
+	case "summary":
+		OutputSummary(diff, os.Stdout)
 	default: // "table" is the default
 		OutputTable(diff, os.Stdout)
 	}
 }
 
+// TableOptions controls the optional columns of OutputTableWithOptions
+type TableOptions struct {
+	ShowAge bool // Add an AGE column derived from each resource's creation timestamp
+}
+
 // OutputTable outputs the diff as a table
 func OutputTable(diff *DiffResult, writer io.Writer) {
+	OutputTableWithOptions(diff, writer, TableOptions{})
+}
+
+// OutputTableWithOptions outputs the diff as a table with the given optional columns
+func OutputTableWithOptions(diff *DiffResult, writer io.Writer, opts TableOptions) {
 	// Initialize tabwriter
 	w := tabwriter.NewWriter(writer, 0, 0, 3, ' ', tabwriter.TabIndent)
 
@@ -129,37 +637,53 @@ func OutputTable(diff *DiffResult, writer io.Writer) {
 	removeColor := color.New(color.FgRed).SprintFunc()
 	modifyColor := color.New(color.FgYellow).SprintFunc()
 
+	// Trailing columns: the severity, then the optional ones
+	header := "OPERATION\tKIND\tNAMESPACE\tNAME\tRESOURCE VERSION\tSPEC HASH\tSEVERITY"
+	extra := func(res ResourceDiff) string { return "\t" + severityColor(res.Severity)(res.Severity) }
+	if opts.ShowAge {
+		header += "\tAGE"
+		extra = func(res ResourceDiff) string {
+			return "\t" + severityColor(res.Severity)(res.Severity) + "\t" + humanizeAge(res.Resource.CreationTimestamp)
+		}
+	}
+
 	// Print header
-	fmt.Fprintln(w, "OPERATION\tKIND\tNAMESPACE\tNAME\tRESOURCE VERSION\tSPEC HASH")
+	fmt.Fprintln(w, header)
 
 	// Print added resources
 	for _, res := range diff.Added {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s%s\n",
 			addColor("Added"),
 			res.Resource.GroupVersionKind,
 			res.Resource.Namespace,
 			res.Resource.Name,
 			res.Resource.ResourceVersion,
 			res.Resource.SpecHash,
+			extra(res),
 		)
 	}
 
 	// Print removed resources
 	for _, res := range diff.Removed {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s%s\n",
 			removeColor("Removed"),
 			res.Resource.GroupVersionKind,
 			res.Resource.Namespace,
 			res.Resource.Name,
 			res.Resource.ResourceVersion,
 			res.Resource.SpecHash,
+			extra(res),
 		)
 	}
 
-	// Print modified resources
+	// Print modified resources, in red if they changed an immutable field
 	for _, res := range diff.Modified {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s -> %s\t%s -> %s\n",
-			modifyColor("Modified"),
+		opColor := modifyColor
+		if len(res.ImmutableFields) > 0 {
+			opColor = removeColor
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s -> %s\t%s -> %s%s\n",
+			opColor("Modified"),
 			res.Resource.GroupVersionKind,
 			res.Resource.Namespace,
 			res.Resource.Name,
@@ -167,9 +691,15 @@ func OutputTable(diff *DiffResult, writer io.Writer) {
 			res.NewResourceVersion,
 			res.OldSpecHash,
 			res.NewSpecHash,
+			extra(res),
 		)
 	}
 
+	// Note the changes left out by LimitPerKind
+	for _, kind := range diff.TruncatedKinds() {
+		fmt.Fprintf(w, "…\t%s\t\tand %d more\t\t\n", kind, diff.Truncated[kind])
+	}
+
 	w.Flush()
 }
 
@@ -180,6 +710,11 @@ func OutputJSON(diff *DiffResult, writer io.Writer) {
 	encoder.Encode(diff)
 }
 
+// OutputCompactJSON outputs the diff as single-line JSON
+func OutputCompactJSON(diff *DiffResult, writer io.Writer) {
+	json.NewEncoder(writer).Encode(diff)
+}
+
 // OutputYAML outputs the diff as YAML
 func OutputYAML(diff *DiffResult, writer io.Writer) {
 	data, err := yaml.Marshal(diff)
@@ -191,6 +726,62 @@ func OutputYAML(diff *DiffResult, writer io.Writer) {
 	writer.Write(data)
 }
 
+// YAMLPieces splits the YAML written by OutputYAML into pieces to render in
+// order: the section keys, one piece per changed resource and the remaining
+// fields. Rendering a few at a time shows the start of a huge diff right away.
+func YAMLPieces(diff *DiffResult) []func() string {
+	var pieces []func() string
+	for _, section := range []struct {
+		key   string
+		diffs []ResourceDiff
+	}{{"added", diff.Added}, {"removed", diff.Removed}, {"modified", diff.Modified}} {
+		if len(section.diffs) == 0 {
+			key := section.key
+			pieces = append(pieces, func() string { return key + ": []\n" })
+			continue
+		}
+
+		key := section.key
+		pieces = append(pieces, func() string { return key + ":\n" })
+		for i := range section.diffs {
+			res := &section.diffs[i]
+			pieces = append(pieces, func() string { return yamlListItem(res) })
+		}
+	}
+
+	// Everything after the resources, without the empty sections rendered above
+	pieces = append(pieces, func() string {
+		data, err := yaml.Marshal(&DiffResult{Warnings: diff.Warnings, Truncated: diff.Truncated})
+		if err != nil {
+			return ""
+		}
+		return strings.SplitAfterN(string(data), "\n", 4)[3]
+	})
+	return pieces
+}
+
+// yamlListItem renders a value as an entry of a YAML list, as yaml.Marshal does
+// for lists in a map
+func yamlListItem(value interface{}) string {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return ""
+	}
+
+	var item strings.Builder
+	for i, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		switch {
+		case i == 0:
+			item.WriteString("- " + line + "\n")
+		case line == "":
+			item.WriteString("\n")
+		default:
+			item.WriteString("  " + line + "\n")
+		}
+	}
+	return item.String()
+}
+
 // outputTable is maintained for backward compatibility
 func outputTable(diff *DiffResult, writer io.Writer) {
 	OutputTable(diff, writer)
@@ -0,0 +1,103 @@
+package diff
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+func change(op DiffType, gvk, namespace, name string) ResourceDiff {
+	return ResourceDiff{Type: op, Resource: snapshot.ResourceInfo{GroupVersionKind: gvk, Namespace: namespace, Name: name}}
+}
+
+// changeNames returns the names of every change in a result, added first
+func changeNames(result *DiffResult) []string {
+	var names []string
+	for _, diffs := range [][]ResourceDiff{result.Added, result.Removed, result.Modified} {
+		for _, res := range diffs {
+			names = append(names, res.Resource.Name)
+		}
+	}
+	return names
+}
+
+func TestFilter(t *testing.T) {
+	result := &DiffResult{
+		Added: []ResourceDiff{
+			change(Added, "apps/v1/Deployment", "payments", "web"),
+			change(Added, "v1/ConfigMap", "billing", "web-config"),
+		},
+		Removed: []ResourceDiff{
+			change(Removed, "apps/v1/Deployment", "billing", "worker"),
+			change(Removed, "rbac.authorization.k8s.io/v1/ClusterRole", "", "reader"),
+		},
+		Modified: []ResourceDiff{
+			change(Modified, "v1/ConfigMap", "payments", "settings"),
+			change(Modified, "example.com/v1/Deployment", "payments", "web-custom"),
+		},
+		Warnings: []string{"kept"},
+	}
+
+	tests := []struct {
+		name string
+		opts FilterOptions
+		want []string
+	}{
+		{name: "empty", opts: FilterOptions{}, want: []string{"web", "web-config", "worker", "reader", "settings", "web-custom"}},
+		{name: "operation", opts: FilterOptions{Operations: []DiffType{Removed}}, want: []string{"worker", "reader"}},
+		{name: "operations", opts: FilterOptions{Operations: []DiffType{Added, Modified}}, want: []string{"web", "web-config", "settings", "web-custom"}},
+		{name: "kind name", opts: FilterOptions{Kinds: []string{"deployment"}}, want: []string{"web", "worker", "web-custom"}},
+		{name: "full resource type", opts: FilterOptions{Kinds: []string{"apps/v1/Deployment"}}, want: []string{"web", "worker"}},
+		{name: "namespace", opts: FilterOptions{Namespaces: []string{"billing"}}, want: []string{"web-config", "worker"}},
+		{name: "cluster-scoped", opts: FilterOptions{Namespaces: []string{""}}, want: []string{"reader"}},
+		{name: "name pattern", opts: FilterOptions{NamePattern: regexp.MustCompile("^web")}, want: []string{"web", "web-config", "web-custom"}},
+		{
+			name: "operation and kind",
+			opts: FilterOptions{Operations: []DiffType{Added}, Kinds: []string{"ConfigMap"}},
+			want: []string{"web-config"},
+		},
+		{
+			name: "kind and namespace",
+			opts: FilterOptions{Kinds: []string{"Deployment"}, Namespaces: []string{"payments"}},
+			want: []string{"web", "web-custom"},
+		},
+		{
+			name: "all dimensions",
+			opts: FilterOptions{
+				Operations:  []DiffType{Added, Modified},
+				Kinds:       []string{"Deployment", "ConfigMap"},
+				Namespaces:  []string{"payments"},
+				NamePattern: regexp.MustCompile("^web"),
+			},
+			want: []string{"web", "web-custom"},
+		},
+		{name: "nothing matches", opts: FilterOptions{Operations: []DiffType{Removed}, Namespaces: []string{"payments"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := result.Filter(tt.opts)
+			if got := changeNames(filtered); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter() kept %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(filtered.Warnings, result.Warnings) {
+				t.Errorf("Filter() warnings = %q, want %q", filtered.Warnings, result.Warnings)
+			}
+		})
+	}
+}
+
+func TestFilterUnchanged(t *testing.T) {
+	result := &DiffResult{Unchanged: []ResourceDiff{
+		change(Unchanged, "v1/ConfigMap", "payments", "settings"),
+		change(Unchanged, "v1/ConfigMap", "billing", "settings"),
+	}}
+
+	// Unchanged resources are filtered by everything but the type of change
+	filtered := result.Filter(FilterOptions{Operations: []DiffType{Added}, Namespaces: []string{"payments"}})
+	if len(filtered.Unchanged) != 1 || filtered.Unchanged[0].Resource.Namespace != "payments" {
+		t.Errorf("Filter() kept unchanged %+v, want only payments/settings", filtered.Unchanged)
+	}
+}
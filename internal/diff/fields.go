@@ -0,0 +1,216 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+	"gopkg.in/yaml.v2"
+)
+
+// FieldPath is a parsed path into a manifest such as spec.replicas or
+// spec.template.spec.containers[*].image. A segment is a map key, optionally
+// followed by list indexes: [N] for one entry or [*] for all of them.
+type FieldPath struct {
+	path     string
+	segments []pathSegment
+}
+
+// pathSegment is one step of a FieldPath: a map key or a list index
+type pathSegment struct {
+	key   string // Map key; empty for list indexes
+	index int    // List index, or -1 for every entry
+	list  bool   // Whether this is a list index rather than a map key
+}
+
+// ParseFieldPath parses a dotted path with optional [N] and [*] list indexes
+func ParseFieldPath(path string) (FieldPath, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(path), ".")
+	if trimmed == "" {
+		return FieldPath{}, fmt.Errorf("invalid field path %q: empty", path)
+	}
+
+	parsed := FieldPath{path: trimmed}
+	for _, part := range strings.Split(trimmed, ".") {
+		key := part
+		if i := strings.Index(part, "["); i >= 0 {
+			key = part[:i]
+		}
+		if key == "" {
+			return FieldPath{}, fmt.Errorf("invalid field path %q: empty key in %q", path, part)
+		}
+		parsed.segments = append(parsed.segments, pathSegment{key: key})
+
+		for rest := part[len(key):]; rest != ""; {
+			end := strings.Index(rest, "]")
+			if rest[0] != '[' || end < 0 {
+				return FieldPath{}, fmt.Errorf("invalid field path %q: malformed index in %q", path, part)
+			}
+
+			index := -1
+			if inner := rest[1:end]; inner != "*" {
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return FieldPath{}, fmt.Errorf("invalid field path %q: index %q must be a number or *", path, inner)
+				}
+				index = n
+			}
+			parsed.segments = append(parsed.segments, pathSegment{index: index, list: true})
+			rest = rest[end+1:]
+		}
+	}
+	return parsed, nil
+}
+
+// String returns the path as given
+func (p FieldPath) String() string {
+	return p.path
+}
+
+// extract returns the values at the path in a decoded manifest, in document order.
+// Missing keys and indexes yield no values.
+func (p FieldPath) extract(value interface{}) []interface{} {
+	values := []interface{}{value}
+	for _, segment := range p.segments {
+		var next []interface{}
+		for _, v := range values {
+			if segment.list {
+				list, ok := v.([]interface{})
+				if !ok {
+					continue
+				}
+				if segment.index < 0 {
+					next = append(next, list...)
+				} else if segment.index < len(list) {
+					next = append(next, list[segment.index])
+				}
+				continue
+			}
+
+			switch m := v.(type) {
+			case map[interface{}]interface{}:
+				if child, ok := m[segment.key]; ok {
+					next = append(next, child)
+				}
+			case map[string]interface{}:
+				if child, ok := m[segment.key]; ok {
+					next = append(next, child)
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// watchedFieldsChanged reports whether any of the paths has different values in
+// the two YAML manifests. ok is false if a manifest is missing or can't be parsed.
+func watchedFieldsChanged(paths []FieldPath, baseManifest, manifest string) (changed, ok bool) {
+	if baseManifest == "" || manifest == "" {
+		return false, false
+	}
+
+	var base, current interface{}
+	if yaml.Unmarshal([]byte(baseManifest), &base) != nil || yaml.Unmarshal([]byte(manifest), &current) != nil {
+		return false, false
+	}
+
+	for _, path := range paths {
+		if !reflect.DeepEqual(path.extract(base), path.extract(current)) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// missingWatchedFields returns the watched paths found in no manifest of the
+// resources in both snapshots. Changes to them can't be seen, so they'd silently
+// count as unchanged, e.g. after a typo in the path.
+func missingWatchedFields(paths []FieldPath, baseline, current *snapshot.Snapshot) []string {
+	missing := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		missing[path.String()] = true
+	}
+
+	for key, res := range current.Resources {
+		baseRes, ok := baseline.Resources[key]
+		if !ok {
+			continue
+		}
+		for _, manifest := range []string{baseRes.Manifest, res.Manifest} {
+			var decoded interface{}
+			if manifest == "" || yaml.Unmarshal([]byte(manifest), &decoded) != nil {
+				continue
+			}
+			for _, path := range paths {
+				if missing[path.String()] && len(path.extract(decoded)) > 0 {
+					delete(missing, path.String())
+				}
+			}
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+	}
+
+	var unseen []string
+	for _, path := range paths {
+		if missing[path.String()] {
+			unseen = append(unseen, path.String())
+			delete(missing, path.String())
+		}
+	}
+	return unseen
+}
+
+// FieldChange is a watched field whose value differs between the baseline and
+// current manifests
+type FieldChange struct {
+	Path     string
+	OldValue string
+	NewValue string
+}
+
+// FieldChanges returns the watched fields that changed in a modified resource,
+// in the order of paths. Values are formatted for display; a field that's missing
+// shows as <none>.
+func (r ResourceDiff) FieldChanges(paths []FieldPath) []FieldChange {
+	if r.BaselineResource == nil || r.CurrentResource == nil {
+		return nil
+	}
+
+	var base, current interface{}
+	_ = yaml.Unmarshal([]byte(r.BaselineResource.Manifest), &base)
+	_ = yaml.Unmarshal([]byte(r.CurrentResource.Manifest), &current)
+
+	var changes []FieldChange
+	for _, path := range paths {
+		oldValues, newValues := path.extract(base), path.extract(current)
+		if !reflect.DeepEqual(oldValues, newValues) {
+			changes = append(changes, FieldChange{
+				Path:     path.String(),
+				OldValue: formatFieldValues(oldValues),
+				NewValue: formatFieldValues(newValues),
+			})
+		}
+	}
+	return changes
+}
+
+// formatFieldValues formats the values found at a path on one line
+func formatFieldValues(values []interface{}) string {
+	switch len(values) {
+	case 0:
+		return "<none>"
+	case 1:
+		return fmt.Sprint(values[0])
+	}
+
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = fmt.Sprint(value)
+	}
+	return "[" + strings.Join(formatted, ", ") + "]"
+}
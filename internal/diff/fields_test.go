@@ -0,0 +1,70 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// manifestSnapshot returns a snapshot holding one resource per manifest, keyed by name
+func manifestSnapshot(gvk string, manifests map[string]string) *snapshot.Snapshot {
+	snap := &snapshot.Snapshot{Resources: map[string]snapshot.ResourceInfo{}}
+	for name, manifest := range manifests {
+		res := snapshot.ResourceInfo{GroupVersionKind: gvk, Namespace: "payments", Name: name, SpecHash: manifest, Manifest: manifest}
+		snap.Resources[res.Key()] = res
+	}
+	return snap
+}
+
+func TestWatchFields(t *testing.T) {
+	baseline := manifestSnapshot("rbac.authorization.k8s.io/v1/Role", map[string]string{
+		"reader":   "rules:\n- verbs: [get]\n",
+		"deployer": "rules:\n- verbs: [get]\n",
+	})
+	current := manifestSnapshot("rbac.authorization.k8s.io/v1/Role", map[string]string{
+		"reader":   "rules:\n- verbs: [get]\n",
+		"deployer": "rules:\n- verbs: [get, delete]\n",
+	})
+
+	tests := []struct {
+		name         string
+		paths        []string
+		wantModified []string
+		wantWarning  string
+	}{
+		{name: "watched field changed", paths: []string{"rules[*].verbs"}, wantModified: []string{"deployer"}},
+		{name: "other field watched", paths: []string{"rules[*].apiGroups"}, wantWarning: "watched field rules[*].apiGroups isn't in any compared manifest"},
+		{name: "typo", paths: []string{"rules[*].verbs", "rule"}, wantModified: []string{"deployer"}, wantWarning: "watched field rule isn't"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []FieldPath
+			for _, path := range tt.paths {
+				parsed, err := ParseFieldPath(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				paths = append(paths, parsed)
+			}
+
+			result := CompareWithOptions(baseline, current, CompareOptions{WatchFields: paths})
+			var modified []string
+			for _, res := range result.Modified {
+				modified = append(modified, res.Resource.Name)
+			}
+			if strings.Join(modified, ",") != strings.Join(tt.wantModified, ",") {
+				t.Errorf("modified = %q, want %q", modified, tt.wantModified)
+			}
+
+			warnings := strings.Join(result.Warnings, "\n")
+			if tt.wantWarning == "" && warnings != "" {
+				t.Errorf("unexpected warnings: %s", warnings)
+			}
+			if tt.wantWarning != "" && !strings.Contains(warnings, tt.wantWarning) {
+				t.Errorf("warnings %q don't mention %q", warnings, tt.wantWarning)
+			}
+		})
+	}
+}
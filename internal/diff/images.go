@@ -0,0 +1,127 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ImageChange is a container whose image differs between the baseline and current
+// manifests of a modified workload
+type ImageChange struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Container string `json:"container"`
+	OldImage  string `json:"oldImage,omitempty"` // Empty if the container was added
+	NewImage  string `json:"newImage,omitempty"` // Empty if the container was removed
+}
+
+// containerPaths are where workloads keep their containers: pods, pod templates
+// (Deployments, StatefulSets, DaemonSets, ReplicaSets, Jobs) and CronJob templates
+var containerPaths = mustParseFieldPaths(
+	"spec.containers[*]",
+	"spec.initContainers[*]",
+	"spec.template.spec.containers[*]",
+	"spec.template.spec.initContainers[*]",
+	"spec.jobTemplate.spec.template.spec.containers[*]",
+	"spec.jobTemplate.spec.template.spec.initContainers[*]",
+)
+
+// mustParseFieldPaths parses paths that are known to be valid
+func mustParseFieldPaths(paths ...string) []FieldPath {
+	parsed := make([]FieldPath, len(paths))
+	for i, path := range paths {
+		var err error
+		if parsed[i], err = ParseFieldPath(path); err != nil {
+			panic(err)
+		}
+	}
+	return parsed
+}
+
+// ImageChanges lists the container images that changed in the modified resources,
+// e.g. to verify a rollout. Resources without containers are skipped.
+func (d *DiffResult) ImageChanges() []ImageChange {
+	var changes []ImageChange
+	for _, res := range d.Modified {
+		if res.BaselineResource == nil || res.CurrentResource == nil {
+			continue
+		}
+
+		oldImages, oldNames := containerImages(res.BaselineResource.Manifest)
+		newImages, newNames := containerImages(res.CurrentResource.Manifest)
+
+		change := func(container string) {
+			if oldImages[container] != newImages[container] {
+				changes = append(changes, ImageChange{
+					Kind:      res.Resource.GroupVersionKind,
+					Namespace: res.Resource.Namespace,
+					Name:      res.Resource.Name,
+					Container: container,
+					OldImage:  oldImages[container],
+					NewImage:  newImages[container],
+				})
+			}
+		}
+		for _, container := range newNames {
+			change(container)
+		}
+		for _, container := range oldNames {
+			if _, ok := newImages[container]; !ok {
+				change(container)
+			}
+		}
+	}
+	return changes
+}
+
+// containerImages returns the image of each container in a YAML manifest by
+// container name, along with the names in manifest order
+func containerImages(manifest string) (map[string]string, []string) {
+	images := map[string]string{}
+	var names []string
+
+	var decoded interface{}
+	if manifest == "" || yaml.Unmarshal([]byte(manifest), &decoded) != nil {
+		return images, names
+	}
+
+	for _, path := range containerPaths {
+		for _, container := range path.extract(decoded) {
+			fields, ok := container.(map[interface{}]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := fields["name"].(string)
+			image, _ := fields["image"].(string)
+			if _, seen := images[name]; !seen {
+				names = append(names, name)
+			}
+			images[name] = image
+		}
+	}
+	return images, names
+}
+
+// OutputImageChanges outputs the image changes as a table, one container per row
+func OutputImageChanges(changes []ImageChange, writer io.Writer) {
+	w := tabwriter.NewWriter(writer, 0, 0, 3, ' ', tabwriter.TabIndent)
+	fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tCONTAINER\tIMAGE")
+
+	for _, change := range changes {
+		oldImage, newImage := change.OldImage, change.NewImage
+		if oldImage == "" {
+			oldImage = "(none)"
+		}
+		if newImage == "" {
+			newImage = "(removed)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s → %s\n",
+			change.Kind, change.Namespace, change.Name, change.Container, oldImage, newImage)
+	}
+
+	w.Flush()
+}
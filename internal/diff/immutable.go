@@ -0,0 +1,106 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultImmutableFields maps kinds to manifest fields the API server won't let
+// change after creation. Changing one means the resource has to be deleted and
+// recreated, e.g. a StatefulSet whose volumeClaimTemplates were edited.
+func DefaultImmutableFields() map[string][]FieldPath {
+	return map[string][]FieldPath{
+		"Deployment":            mustParseFieldPaths("spec.selector"),
+		"ReplicaSet":            mustParseFieldPaths("spec.selector"),
+		"DaemonSet":             mustParseFieldPaths("spec.selector"),
+		"StatefulSet":           mustParseFieldPaths("spec.selector", "spec.serviceName", "spec.volumeClaimTemplates", "spec.podManagementPolicy"),
+		"Job":                   mustParseFieldPaths("spec.selector", "spec.template", "spec.completionMode"),
+		"Service":               mustParseFieldPaths("spec.clusterIP", "spec.clusterIPs"),
+		"PersistentVolumeClaim": mustParseFieldPaths("spec.storageClassName", "spec.accessModes", "spec.volumeName", "spec.volumeMode", "spec.selector"),
+		"PersistentVolume":      mustParseFieldPaths("spec.volumeMode"),
+		"StorageClass":          mustParseFieldPaths("provisioner", "parameters", "reclaimPolicy", "volumeBindingMode"),
+		"Secret":                mustParseFieldPaths("type"),
+		"RoleBinding":           mustParseFieldPaths("roleRef"),
+		"ClusterRoleBinding":    mustParseFieldPaths("roleRef"),
+		"CSIDriver":             mustParseFieldPaths("spec.attachRequired", "spec.podInfoOnMount"),
+	}
+}
+
+// ParseImmutableField parses a KIND=FIELD rule such as
+// PersistentVolumeClaim=spec.storageClassName. KIND is a kind name, or a full
+// resource type like apps/v1/Deployment.
+func ParseImmutableField(rule string) (string, FieldPath, error) {
+	parts := strings.SplitN(rule, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", FieldPath{}, fmt.Errorf("invalid immutable field %q: expected KIND=FIELD, e.g. StatefulSet=spec.serviceName", rule)
+	}
+
+	path, err := ParseFieldPath(parts[1])
+	if err != nil {
+		return "", FieldPath{}, fmt.Errorf("invalid immutable field %q: %v", rule, err)
+	}
+	return parts[0], path, nil
+}
+
+// immutableFields returns the default immutable fields with the options' extra
+// ones added
+func (o CompareOptions) immutableFields() map[string][]FieldPath {
+	fields := DefaultImmutableFields()
+	for kind, paths := range o.ImmutableFields {
+		fields[kind] = append(fields[kind], paths...)
+	}
+	return fields
+}
+
+// immutableFieldsOf returns the immutable fields of a resource type (GVK),
+// preferring an entry for the full type over one for its kind name
+func immutableFieldsOf(gvk string, fields map[string][]FieldPath) []FieldPath {
+	if paths, ok := fields[gvk]; ok {
+		return paths
+	}
+	kind := gvk[strings.LastIndex(gvk, "/")+1:]
+	return fields[kind]
+}
+
+// changedFields returns the paths whose values differ between the two YAML
+// manifests. Nothing is reported if a manifest is missing or can't be parsed.
+func changedFields(paths []FieldPath, baseManifest, manifest string) []string {
+	if len(paths) == 0 || baseManifest == "" || manifest == "" {
+		return nil
+	}
+
+	var base, current interface{}
+	if yaml.Unmarshal([]byte(baseManifest), &base) != nil || yaml.Unmarshal([]byte(manifest), &current) != nil {
+		return nil
+	}
+
+	var changed []string
+	for _, path := range paths {
+		if !reflect.DeepEqual(path.extract(base), path.extract(current)) {
+			changed = append(changed, path.String())
+		}
+	}
+	return changed
+}
+
+// ImmutableFieldWarnings describes each modified resource that changed an
+// immutable field, which can only be applied by recreating the resource
+func (d *DiffResult) ImmutableFieldWarnings() []string {
+	var warnings []string
+	for _, res := range d.Modified {
+		if len(res.ImmutableFields) == 0 {
+			continue
+		}
+
+		name := res.Resource.Name
+		if res.Resource.Namespace != "" {
+			name = res.Resource.Namespace + "/" + name
+		}
+		warnings = append(warnings, fmt.Sprintf("immutable field changed in %s %s (%s); applying it requires recreating the resource",
+			res.Resource.GroupVersionKind, name, strings.Join(res.ImmutableFields, ", ")))
+	}
+	return warnings
+}
@@ -0,0 +1,91 @@
+package diff
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// junitTestSuite is the root element of a JUnit XML report
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is a single test case, failing when Failure is set
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+// junitFailure describes why a test case failed
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// OutputJUnit outputs the diff as a JUnit XML test suite so drift shows up in CI
+// test dashboards. Each changed resource is a failing test case; a clean diff is
+// a single passing case.
+func OutputJUnit(diff *DiffResult, writer io.Writer) {
+	suite := junitTestSuite{Name: "k8s-rdiff"}
+
+	for _, diffs := range [][]ResourceDiff{diff.Added, diff.Removed, diff.Modified} {
+		for _, res := range diffs {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      resourcePath(res),
+				ClassName: res.Resource.GroupVersionKind,
+				Failure: &junitFailure{
+					Message: fmt.Sprintf("%s since baseline", strings.ToLower(string(res.Type))),
+					Type:    string(res.Type),
+					Text:    describeChange(res),
+				},
+			})
+		}
+	}
+
+	if len(suite.TestCases) == 0 {
+		suite.TestCases = append(suite.TestCases, junitTestCase{Name: "no drift", ClassName: "k8s-rdiff"})
+	}
+	suite.Tests = len(suite.TestCases)
+	suite.Failures = len(diff.Added) + len(diff.Removed) + len(diff.Modified)
+
+	io.WriteString(writer, xml.Header)
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshaling to JUnit XML: %v\n", err)
+		return
+	}
+	io.WriteString(writer, "\n")
+}
+
+// resourcePath identifies a resource as namespace/name, or just name when cluster-scoped
+func resourcePath(res ResourceDiff) string {
+	if res.Resource.Namespace == "" {
+		return res.Resource.Name
+	}
+	return res.Resource.Namespace + "/" + res.Resource.Name
+}
+
+// describeChange summarizes the versions and spec hashes involved in a change
+func describeChange(res ResourceDiff) string {
+	switch res.Type {
+	case Added:
+		return fmt.Sprintf("%s %s was added (resourceVersion %s, spec hash %s)",
+			res.Resource.GroupVersionKind, resourcePath(res), res.Resource.ResourceVersion, res.Resource.SpecHash)
+	case Removed:
+		return fmt.Sprintf("%s %s was removed (resourceVersion %s, spec hash %s)",
+			res.Resource.GroupVersionKind, resourcePath(res), res.Resource.ResourceVersion, res.Resource.SpecHash)
+	default:
+		return fmt.Sprintf("%s %s was modified (resourceVersion %s -> %s, spec hash %s -> %s)",
+			res.Resource.GroupVersionKind, resourcePath(res), res.OldResourceVersion, res.NewResourceVersion, res.OldSpecHash, res.NewSpecHash)
+	}
+}
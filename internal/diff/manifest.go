@@ -0,0 +1,177 @@
+package diff
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// ManifestDiff renders a line diff between two YAML manifests. Removed lines are
+// prefixed with "- ", added lines with "+ " and unchanged text is kept as-is.
+// If style is set it decorates each removed (Removed) and added (Added) line.
+func ManifestDiff(oldYAML, newYAML string, style func(change DiffType, line string) string) string {
+	// Diff whole lines so a changed value shows as a removed and an added line
+	diffs := lineDiffs(oldYAML, newYAML)
+
+	var result strings.Builder
+
+	writeLines := func(change DiffType, prefix, text string) {
+		for _, line := range strings.Split(text, "\n") {
+			if line == "" {
+				continue
+			}
+			line = fmt.Sprintf("%s %s\n", prefix, line)
+			if style != nil {
+				line = style(change, line)
+			}
+			result.WriteString(line)
+		}
+	}
+
+	for _, d := range diffs {
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			writeLines(Removed, "-", d.Text)
+		case diffmatchpatch.DiffInsert:
+			writeLines(Added, "+", d.Text)
+		case diffmatchpatch.DiffEqual:
+			// Add context lines without prefix
+			result.WriteString(d.Text)
+		}
+	}
+
+	return result.String()
+}
+
+// firstLineRune is the rune the first distinct line is mapped to by lineDiffs,
+// past the surrogate range so every rune is valid
+const firstLineRune = 0xE000
+
+// maxDistinctLines is how many distinct lines fit in the runes from firstLineRune
+const maxDistinctLines = utf8.MaxRune - firstLineRune + 1
+
+// lineDiffs diffs two texts line by line. Each distinct line is mapped to a single
+// rune so the character diff can't split lines apart (go-diff's own DiffLinesToChars
+// encodes line numbers as text, which mangles diffs of more than a few lines).
+// Texts with more distinct lines than there are runes are diffed as a whole.
+func lineDiffs(oldText, newText string) []diffmatchpatch.Diff {
+	lineRunes := map[string]rune{}
+	lines := []string{}
+	overflow := false
+	toRunes := func(text string) []rune {
+		var runes []rune
+		for _, line := range strings.SplitAfter(text, "\n") {
+			if line == "" {
+				continue
+			}
+			r, ok := lineRunes[line]
+			if !ok {
+				if len(lines) == maxDistinctLines {
+					overflow = true
+					return nil
+				}
+				r = rune(firstLineRune + len(lines))
+				lineRunes[line] = r
+				lines = append(lines, line)
+			}
+			runes = append(runes, r)
+		}
+		return runes
+	}
+	oldRunes, newRunes := toRunes(oldText), toRunes(newText)
+	if overflow {
+		return wholeTextDiffs(oldText, newText)
+	}
+
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMainRunes(oldRunes, newRunes, false)
+	for i, d := range diffs {
+		var text strings.Builder
+		for _, r := range d.Text {
+			text.WriteString(lines[r-firstLineRune])
+		}
+		diffs[i].Text = text.String()
+	}
+	return diffs
+}
+
+// wholeTextDiffs diffs two texts as a single removal and addition, unless they're equal
+func wholeTextDiffs(oldText, newText string) []diffmatchpatch.Diff {
+	if oldText == newText {
+		return []diffmatchpatch.Diff{{Type: diffmatchpatch.DiffEqual, Text: oldText}}
+	}
+
+	var diffs []diffmatchpatch.Diff
+	if oldText != "" {
+		diffs = append(diffs, diffmatchpatch.Diff{Type: diffmatchpatch.DiffDelete, Text: oldText})
+	}
+	if newText != "" {
+		diffs = append(diffs, diffmatchpatch.Diff{Type: diffmatchpatch.DiffInsert, Text: newText})
+	}
+	return diffs
+}
+
+// unsafeFileChars matches characters that don't belong in a diff file name
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// DiffFileName returns the file name WriteDiffFiles uses for a resource:
+// <op>-<kind>-<ns>-<name>.yaml.diff, with "cluster" for cluster-scoped resources
+func DiffFileName(res ResourceDiff) string {
+	gvk := res.Resource.GroupVersionKind
+	kind := gvk[strings.LastIndex(gvk, "/")+1:]
+
+	namespace := res.Resource.Namespace
+	if namespace == "" {
+		namespace = "cluster"
+	}
+
+	parts := []string{strings.ToLower(string(res.Type)), strings.ToLower(kind), namespace, res.Resource.Name}
+	for i, part := range parts {
+		parts[i] = unsafeFileChars.ReplaceAllString(part, "_")
+	}
+	return strings.Join(parts, "-") + ".yaml.diff"
+}
+
+// WriteDiffFiles writes one manifest diff per changed resource into dir, creating
+// it if needed. Added and removed resources are written as all-added or all-removed
+// diffs. It returns the number of files written.
+func WriteDiffFiles(diff *DiffResult, dir string) (int, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	written := 0
+	seen := map[string]int{}
+	for _, diffs := range [][]ResourceDiff{diff.Added, diff.Removed, diff.Modified} {
+		for _, res := range diffs {
+			var oldManifest, newManifest string
+			if res.BaselineResource != nil {
+				oldManifest = res.BaselineResource.Manifest
+			}
+			if res.CurrentResource != nil {
+				newManifest = res.CurrentResource.Manifest
+			}
+
+			// The same kind can exist in several API groups, so keep names unique
+			name := DiffFileName(res)
+			seen[name]++
+			if n := seen[name]; n > 1 {
+				name = fmt.Sprintf("%s-%d.yaml.diff", strings.TrimSuffix(name, ".yaml.diff"), n)
+			}
+
+			content := ManifestDiff(oldManifest, newManifest, nil)
+			if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				return written, fmt.Errorf("failed to write diff file: %v", err)
+			}
+			written++
+		}
+	}
+
+	return written, nil
+}
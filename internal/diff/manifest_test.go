@@ -0,0 +1,85 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// manifestSeeds are pathological manifests, as any cluster (or a hand-edited
+// snapshot) might hold
+var manifestSeeds = []string{
+	"",
+	"\n\n\n",
+	"apiVersion: v1\nkind: ConfigMap\ndata:\n  a: \"1\"\n",
+	"apiVersion: v1\nkind: ConfigMap\ndata:\n  a: \"2\"\n  b: \"3\"\n",
+	"no trailing newline",
+	"spec: [unterminated\n",
+	"{\"apiVersion\": \"v1\", \"kind\": \"Secret\", \"data\": {\"k\": 1}}",
+	"rules: 5\n",
+	"rules:\n- apiGroups: 5\n  verbs: {a: b}\n",
+	"rules:\n- apiGroups: ['']\n  resources: [secrets]\n  resourceNames: [db]\n  verbs: [get]\n- nonResourceURLs: [/healthz]\n  verbs: ['*']\n",
+	"data: [1, 2]\nbinaryData: hello\n",
+	"a: &a [*a]\n",
+	"- - - - - - - - - - - - - - - - -\n",
+	"\x00\xff\xfe\r\n\r\n\t",
+	strings.Repeat("x", 1<<16),
+	strings.Repeat("key: value\n", 5000),
+	strings.Repeat("  ", 500) + "deep: true\n",
+}
+
+func FuzzManifestDiff(f *testing.F) {
+	for i, seed := range manifestSeeds {
+		f.Add(seed, manifestSeeds[(i+1)%len(manifestSeeds)])
+	}
+
+	f.Fuzz(func(t *testing.T, oldText, newText string) {
+		// Deleted and equal text make up the old text, inserted and equal the new one
+		var old, current strings.Builder
+		for _, d := range lineDiffs(oldText, newText) {
+			if d.Type != diffmatchpatch.DiffInsert {
+				old.WriteString(d.Text)
+			}
+			if d.Type != diffmatchpatch.DiffDelete {
+				current.WriteString(d.Text)
+			}
+		}
+		if old.String() != oldText || current.String() != newText {
+			t.Fatalf("line diffs don't add up to the texts:\nold %q, got %q\nnew %q, got %q", oldText, old.String(), newText, current.String())
+		}
+
+		output := ManifestDiff(oldText, newText, nil)
+		if oldText == newText && output != oldText {
+			t.Fatalf("diff of equal texts = %q, want the text unchanged", output)
+		}
+	})
+}
+
+// FuzzManifestParsing feeds arbitrary manifests to everything that parses them
+// for the detail view
+func FuzzManifestParsing(f *testing.F) {
+	for i, seed := range manifestSeeds {
+		f.Add(seed, manifestSeeds[(i+3)%len(manifestSeeds)])
+	}
+	paths := mustParseFieldPaths("rules[*].verbs", "data", "spec.template.spec.containers[0].image", "roleRef")
+
+	f.Fuzz(func(t *testing.T, oldManifest, newManifest string) {
+		for _, gvk := range []string{"rbac.authorization.k8s.io/v1/ClusterRole", "v1/Secret"} {
+			res := ResourceDiff{
+				Type:             Modified,
+				Resource:         snapshot.ResourceInfo{GroupVersionKind: gvk, Name: "fuzzed"},
+				BaselineResource: &snapshot.ResourceInfo{GroupVersionKind: gvk, Manifest: oldManifest},
+				CurrentResource:  &snapshot.ResourceInfo{GroupVersionKind: gvk, Manifest: newManifest},
+			}
+			for _, change := range res.RBACChanges() {
+				_ = change.String()
+			}
+			res.DataKeyChanges()
+			res.FieldChanges(paths)
+			changedFields(paths, oldManifest, newManifest)
+			watchedFieldsChanged(paths, oldManifest, newManifest)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RBACChange is a set of verbs granted or revoked on one target by a change to the
+// rules of a Role or ClusterRole
+type RBACChange struct {
+	Type   DiffType // Added (granted) or Removed (revoked)
+	Verbs  []string
+	Target string // e.g. "secrets", "deployments.apps", "pods named web" or "/healthz"
+}
+
+// String formats the change compactly, e.g. "+ delete, patch on secrets"
+func (c RBACChange) String() string {
+	marker := "+"
+	if c.Type == Removed {
+		marker = "-"
+	}
+	return fmt.Sprintf("%s %s on %s", marker, strings.Join(c.Verbs, ", "), c.Target)
+}
+
+// policyRule is the part of an rbac.authorization.k8s.io PolicyRule that grants access
+type policyRule struct {
+	APIGroups       []string `yaml:"apiGroups"`
+	Resources       []string `yaml:"resources"`
+	ResourceNames   []string `yaml:"resourceNames"`
+	NonResourceURLs []string `yaml:"nonResourceURLs"`
+	Verbs           []string `yaml:"verbs"`
+}
+
+// RBACChanges returns the permissions a Role or ClusterRole gained and lost, sorted
+// by target. Rules are compared verb by verb, so reordering or splitting rules
+// isn't reported. It is empty for other kinds, and when the manifests are missing
+// or can't be parsed. An added or removed role grants or revokes all of its rules.
+func (r ResourceDiff) RBACChanges() []RBACChange {
+	if !isRBACRole(r.Resource.GroupVersionKind) {
+		return nil
+	}
+
+	var base, current map[string]map[string]bool
+	var ok bool
+	if r.BaselineResource != nil {
+		if base, ok = manifestPermissions(r.BaselineResource.Manifest); !ok {
+			return nil
+		}
+	}
+	if r.CurrentResource != nil {
+		if current, ok = manifestPermissions(r.CurrentResource.Manifest); !ok {
+			return nil
+		}
+	}
+
+	changes := append(permissionChanges(current, base, Added), permissionChanges(base, current, Removed)...)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Target < changes[j].Target
+	})
+	return changes
+}
+
+// isRBACRole reports whether a resource type (GVK) is a Role or ClusterRole
+func isRBACRole(gvk string) bool {
+	return strings.HasPrefix(gvk, "rbac.authorization.k8s.io/") &&
+		(strings.HasSuffix(gvk, "/Role") || strings.HasSuffix(gvk, "/ClusterRole"))
+}
+
+// permissionChanges returns the verbs on each target in from that aren't in to
+func permissionChanges(from, to map[string]map[string]bool, op DiffType) []RBACChange {
+	var changes []RBACChange
+	for target, verbs := range from {
+		var missing []string
+		for verb := range verbs {
+			if !to[target][verb] {
+				missing = append(missing, verb)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			changes = append(changes, RBACChange{Type: op, Verbs: missing, Target: target})
+		}
+	}
+	return changes
+}
+
+// manifestPermissions returns the verbs a role's YAML manifest grants, by target
+func manifestPermissions(manifest string) (map[string]map[string]bool, bool) {
+	if manifest == "" {
+		return nil, false
+	}
+
+	var obj struct {
+		Rules []policyRule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal([]byte(manifest), &obj); err != nil {
+		return nil, false
+	}
+
+	permissions := map[string]map[string]bool{}
+	grant := func(target string, verbs []string) {
+		if permissions[target] == nil {
+			permissions[target] = map[string]bool{}
+		}
+		for _, verb := range verbs {
+			permissions[target][verb] = true
+		}
+	}
+
+	for _, rule := range obj.Rules {
+		for _, url := range rule.NonResourceURLs {
+			grant(url, rule.Verbs)
+		}
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				target := resource
+				if group != "" {
+					target += "." + group
+				}
+				if len(rule.ResourceNames) == 0 {
+					grant(target, rule.Verbs)
+				}
+				for _, name := range rule.ResourceNames {
+					grant(target+" named "+name, rule.Verbs)
+				}
+			}
+		}
+	}
+	return permissions, true
+}
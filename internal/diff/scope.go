@@ -0,0 +1,83 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// scopeOf reports, per resource type (GVK), whether a snapshot holds namespaced
+// and cluster-scoped resources of it. Cluster-scoped resources have an empty namespace.
+func scopeOf(snap *snapshot.Snapshot) (namespaced, clusterScoped map[string]bool) {
+	namespaced, clusterScoped = map[string]bool{}, map[string]bool{}
+	for _, res := range snap.Resources {
+		if res.Namespace == "" {
+			clusterScoped[res.GroupVersionKind] = true
+		} else {
+			namespaced[res.GroupVersionKind] = true
+		}
+	}
+	return namespaced, clusterScoped
+}
+
+// scopeChanges pairs up the resources of types that are namespaced in one snapshot
+// and cluster-scoped in the other (e.g. a CRD that changed scope between versions),
+// which would otherwise show up as removed and added because the namespace is part
+// of the key. It maps current keys to baseline keys, pairing by name when exactly
+// one resource on the namespaced side has it, and describes the types that moved.
+func scopeChanges(baseline, current *snapshot.Snapshot) (map[string]string, []string) {
+	baseNamespaced, baseCluster := scopeOf(baseline)
+	currentNamespaced, currentCluster := scopeOf(current)
+
+	moved := map[string]bool{}
+	for gvk := range baseNamespaced {
+		if currentCluster[gvk] && !currentNamespaced[gvk] && !baseCluster[gvk] {
+			moved[gvk] = true
+		}
+	}
+	for gvk := range baseCluster {
+		if currentNamespaced[gvk] && !currentCluster[gvk] && !baseNamespaced[gvk] {
+			moved[gvk] = true
+		}
+	}
+	if len(moved) == 0 {
+		return nil, nil
+	}
+
+	// Baseline keys by type and name; more than one means the name was in several namespaces
+	type typeName struct{ gvk, name string }
+	byName := map[typeName][]string{}
+	for key, res := range baseline.Resources {
+		if moved[res.GroupVersionKind] {
+			id := typeName{res.GroupVersionKind, res.Name}
+			byName[id] = append(byName[id], key)
+		}
+	}
+
+	pairs := map[string]string{}
+	currentByName := map[typeName]int{}
+	for _, res := range current.Resources {
+		if moved[res.GroupVersionKind] {
+			currentByName[typeName{res.GroupVersionKind, res.Name}]++
+		}
+	}
+	for key, res := range current.Resources {
+		id := typeName{res.GroupVersionKind, res.Name}
+		if moved[res.GroupVersionKind] && len(byName[id]) == 1 && currentByName[id] == 1 {
+			pairs[key] = byName[id][0]
+		}
+	}
+
+	var warnings []string
+	for gvk := range moved {
+		from, to := "namespaced", "cluster-scoped"
+		if baseCluster[gvk] {
+			from, to = to, from
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"%s changed from %s to %s; its resources were matched by name", gvk, from, to))
+	}
+	sort.Strings(warnings)
+	return pairs, warnings
+}
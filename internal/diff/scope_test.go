@@ -0,0 +1,47 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+func widgets(resources ...snapshot.ResourceInfo) *snapshot.Snapshot {
+	snap := &snapshot.Snapshot{Resources: map[string]snapshot.ResourceInfo{}}
+	for _, res := range resources {
+		res.GroupVersionKind = "example.com/v1/Widget"
+		snap.Resources[res.Key()] = res
+	}
+	return snap
+}
+
+func TestCompareScopeChange(t *testing.T) {
+	baseline := widgets(
+		snapshot.ResourceInfo{Namespace: "payments", Name: "a", SpecHash: "1"},
+		snapshot.ResourceInfo{Namespace: "payments", Name: "b", SpecHash: "1"},
+		snapshot.ResourceInfo{Namespace: "payments", Name: "shared", SpecHash: "1"},
+		snapshot.ResourceInfo{Namespace: "billing", Name: "shared", SpecHash: "1"},
+	)
+	current := widgets(
+		snapshot.ResourceInfo{Name: "a", SpecHash: "1"},
+		snapshot.ResourceInfo{Name: "b", SpecHash: "2"},
+		snapshot.ResourceInfo{Name: "shared", SpecHash: "1"},
+	)
+
+	result := Compare(baseline, current)
+
+	if len(result.Modified) != 1 || result.Modified[0].Resource.Name != "b" {
+		t.Errorf("modified = %+v, want only b", result.Modified)
+	}
+	// A name in several namespaces can't be paired
+	if len(result.Added) != 1 || result.Added[0].Resource.Name != "shared" {
+		t.Errorf("added = %+v, want only the cluster-scoped shared", result.Added)
+	}
+	if len(result.Removed) != 2 {
+		t.Errorf("removed = %+v, want both namespaced shared", result.Removed)
+	}
+	if warnings := strings.Join(result.Warnings, "\n"); !strings.Contains(warnings, "example.com/v1/Widget changed from namespaced to cluster-scoped") {
+		t.Errorf("warnings %q don't report the scope change", warnings)
+	}
+}
@@ -0,0 +1,164 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Severity ranks how much a change matters for review, e.g. RBAC changes over ConfigMaps
+type Severity int
+
+const (
+	SeverityLow Severity = iota
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+// severityNames are the names of the severities, in order
+var severityNames = []string{"low", "medium", "high", "critical"}
+
+// String returns the name of the severity, e.g. "high"
+func (s Severity) String() string {
+	if s < SeverityLow || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// MarshalText writes the severity by name in JSON and YAML output
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText reads a severity by name
+func (s *Severity) UnmarshalText(text []byte) error {
+	parsed, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// ParseSeverity parses a severity name (low, medium, high or critical)
+func ParseSeverity(name string) (Severity, error) {
+	for i, severityName := range severityNames {
+		if strings.EqualFold(name, severityName) {
+			return Severity(i), nil
+		}
+	}
+	return SeverityLow, fmt.Errorf("unknown severity %q (use %s)", name, strings.Join(severityNames, ", "))
+}
+
+// DefaultSeverities maps kinds to the severity of their changes. Kinds not listed
+// are low. RBAC, admission webhooks and credentials come first because a change
+// to them can grant access or alter every other workload.
+func DefaultSeverities() map[string]Severity {
+	return map[string]Severity{
+		"ClusterRole":                    SeverityCritical,
+		"ClusterRoleBinding":             SeverityCritical,
+		"MutatingWebhookConfiguration":   SeverityCritical,
+		"ValidatingWebhookConfiguration": SeverityCritical,
+		"CustomResourceDefinition":       SeverityCritical,
+
+		"Secret":              SeverityHigh,
+		"Role":                SeverityHigh,
+		"RoleBinding":         SeverityHigh,
+		"ServiceAccount":      SeverityHigh,
+		"NetworkPolicy":       SeverityHigh,
+		"Namespace":           SeverityHigh,
+		"PersistentVolume":    SeverityHigh,
+		"PodSecurityPolicy":   SeverityHigh,
+		"PodDisruptionBudget": SeverityHigh,
+		"ResourceQuota":       SeverityHigh,
+		"PriorityClass":       SeverityHigh,
+		"StorageClass":        SeverityHigh,
+		"Node":                SeverityHigh,
+
+		"Deployment":            SeverityMedium,
+		"StatefulSet":           SeverityMedium,
+		"DaemonSet":             SeverityMedium,
+		"CronJob":               SeverityMedium,
+		"Job":                   SeverityMedium,
+		"Service":               SeverityMedium,
+		"Ingress":               SeverityMedium,
+		"PersistentVolumeClaim": SeverityMedium,
+		"ConfigMap":             SeverityMedium,
+		"LimitRange":            SeverityMedium,
+	}
+}
+
+// ParseSeverityMapping parses a KIND=SEVERITY rule such as Secret=critical. KIND
+// is a kind name, or a full resource type like apps/v1/Deployment.
+func ParseSeverityMapping(rule string) (string, Severity, error) {
+	parts := strings.SplitN(rule, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", SeverityLow, fmt.Errorf("invalid severity mapping %q: expected KIND=SEVERITY, e.g. Secret=critical", rule)
+	}
+
+	severity, err := ParseSeverity(parts[1])
+	if err != nil {
+		return "", SeverityLow, fmt.Errorf("invalid severity mapping %q: %v", rule, err)
+	}
+	return parts[0], severity, nil
+}
+
+// severityOf returns the severity of a resource type (GVK) from the mapping,
+// preferring an entry for the full type over one for its kind name
+func severityOf(gvk string, severities map[string]Severity) Severity {
+	if severity, ok := severities[gvk]; ok {
+		return severity
+	}
+	kind := gvk[strings.LastIndex(gvk, "/")+1:]
+	return severities[kind]
+}
+
+// severities returns the default mapping with the options' overrides applied
+func (o CompareOptions) severities() map[string]Severity {
+	severities := DefaultSeverities()
+	for kind, severity := range o.Severities {
+		severities[kind] = severity
+	}
+	return severities
+}
+
+// FilterBySeverity returns a new DiffResult without the changes below min
+func (d *DiffResult) FilterBySeverity(min Severity) *DiffResult {
+	if min <= SeverityLow {
+		return d
+	}
+
+	filter := func(diffs []ResourceDiff) []ResourceDiff {
+		filtered := []ResourceDiff{}
+		for _, res := range diffs {
+			if res.Severity >= min {
+				filtered = append(filtered, res)
+			}
+		}
+		return filtered
+	}
+
+	return &DiffResult{
+		Added:    filter(d.Added),
+		Removed:  filter(d.Removed),
+		Modified: filter(d.Modified),
+		Warnings: d.Warnings,
+	}
+}
+
+// severityColor returns the color the table uses for a severity
+func severityColor(severity Severity) func(a ...interface{}) string {
+	switch severity {
+	case SeverityCritical:
+		return color.New(color.FgRed, color.Bold).SprintFunc()
+	case SeverityHigh:
+		return color.New(color.FgRed).SprintFunc()
+	case SeverityMedium:
+		return color.New(color.FgYellow).SprintFunc()
+	default:
+		return fmt.Sprint
+	}
+}
@@ -0,0 +1,69 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// KindSummary counts the changes to one resource type (GVK)
+type KindSummary struct {
+	Kind     string
+	Added    int
+	Removed  int
+	Modified int
+}
+
+// Total returns the number of changed resources of the kind
+func (k KindSummary) Total() int {
+	return k.Added + k.Removed + k.Modified
+}
+
+// SummaryByKind counts the changes per resource type, most changed first
+func (d *DiffResult) SummaryByKind() []KindSummary {
+	byKind := map[string]*KindSummary{}
+	count := func(diffs []ResourceDiff, field func(*KindSummary) *int) {
+		for _, res := range diffs {
+			kind := res.Resource.GroupVersionKind
+			if byKind[kind] == nil {
+				byKind[kind] = &KindSummary{Kind: kind}
+			}
+			*field(byKind[kind])++
+		}
+	}
+	count(d.Added, func(k *KindSummary) *int { return &k.Added })
+	count(d.Removed, func(k *KindSummary) *int { return &k.Removed })
+	count(d.Modified, func(k *KindSummary) *int { return &k.Modified })
+
+	summaries := make([]KindSummary, 0, len(byKind))
+	for _, summary := range byKind {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Total() != summaries[j].Total() {
+			return summaries[i].Total() > summaries[j].Total()
+		}
+		return summaries[i].Kind < summaries[j].Kind
+	})
+	return summaries
+}
+
+// OutputSummary outputs only the number of changes, in total and per resource
+// type, for a short CI log line
+func OutputSummary(diff *DiffResult, writer io.Writer) {
+	total := len(diff.Added) + len(diff.Removed) + len(diff.Modified)
+	fmt.Fprintf(writer, "%d changes: %d added, %d removed, %d modified\n",
+		total, len(diff.Added), len(diff.Removed), len(diff.Modified))
+	if total == 0 {
+		return
+	}
+
+	fmt.Fprintln(writer)
+	w := tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "KIND\tADDED\tREMOVED\tMODIFIED")
+	for _, kind := range diff.SummaryByKind() {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", kind.Kind, kind.Added, kind.Removed, kind.Modified)
+	}
+	w.Flush()
+}
@@ -0,0 +1,84 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/fatih/color"
+)
+
+// templateColors are the colors the color template function accepts
+var templateColors = map[string]color.Attribute{
+	"red":     color.FgRed,
+	"green":   color.FgGreen,
+	"yellow":  color.FgYellow,
+	"blue":    color.FgBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"bold":    color.Bold,
+}
+
+// operationColors are the colors the other outputs use for each operation
+var operationColors = map[DiffType]color.Attribute{
+	Added:    color.FgGreen,
+	Removed:  color.FgRed,
+	Modified: color.FgYellow,
+}
+
+// templateFuncs are the helpers available in --template templates:
+//
+//	color "red" .Resource.Name          colors a value (red, green, yellow, blue, magenta, cyan, bold)
+//	opcolor .Type .Resource.Name        colors a value like the table does for the operation
+//	marker .Type                        the +/-/~ marker of the operation
+//	arrow .OldSpecHash .NewSpecHash     "old → new"
+var templateFuncs = template.FuncMap{
+	"color": func(name string, value interface{}) (string, error) {
+		attr, ok := templateColors[strings.ToLower(name)]
+		if !ok {
+			return "", fmt.Errorf("unknown color %q", name)
+		}
+		return color.New(attr).Sprint(value), nil
+	},
+	"opcolor": func(op DiffType, value interface{}) string {
+		return color.New(operationColors[op]).Sprint(value)
+	},
+	"marker": TreeMarker,
+	"arrow": func(old, new interface{}) string {
+		return fmt.Sprintf("%v → %v", old, new)
+	},
+}
+
+// ParseTemplate parses a Go template for OutputTemplate, with the color, opcolor,
+// marker and arrow helpers available
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("output").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// OutputTemplate runs the diff through a template. With perResource, the template
+// is executed once for each ResourceDiff (added, then removed, then modified) and
+// each result is put on its own line; otherwise it's executed once with the whole
+// DiffResult.
+func OutputTemplate(diff *DiffResult, tmpl *template.Template, writer io.Writer, perResource bool) error {
+	if !perResource {
+		return tmpl.Execute(writer, diff)
+	}
+
+	for _, group := range [][]ResourceDiff{diff.Added, diff.Removed, diff.Modified} {
+		for _, res := range group {
+			var out strings.Builder
+			if err := tmpl.Execute(&out, res); err != nil {
+				return err
+			}
+			line := out.String()
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n"
+			}
+			if _, err := io.WriteString(writer, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/winson-sou/k8s-rdiff/internal/filter"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// OriginalStatus classifies a change between the baseline and current snapshots
+// against an older, original snapshot
+type OriginalStatus string
+
+const (
+	// Reverted changes put the resource back the way it was in the original
+	// snapshot, e.g. a rollback, or a fix that reintroduced an old problem
+	Reverted OriginalStatus = "Reverted"
+
+	// Diverged changes leave the resource different from the original as well
+	Diverged OriginalStatus = "Diverged"
+)
+
+// ThreeWayChange is a change from the baseline to the current snapshot, along
+// with how the current state compares to the original snapshot
+type ThreeWayChange struct {
+	ResourceDiff
+	VsOriginal OriginalStatus `json:"vsOriginal"`
+}
+
+// ThreeWayResult holds the changes from the baseline to the current snapshot,
+// classified against an original snapshot
+type ThreeWayResult struct {
+	Changes  []ThreeWayChange `json:"changes"`
+	Warnings []string         `json:"warnings,omitempty"`
+
+	// Truncated counts, by resource type (GVK), the changes left out by LimitPerKind
+	Truncated map[string]int `json:"truncated,omitempty"`
+}
+
+// Reverted returns the changes that put a resource back to its original state
+func (r *ThreeWayResult) Reverted() []ThreeWayChange {
+	var reverted []ThreeWayChange
+	for _, change := range r.Changes {
+		if change.VsOriginal == Reverted {
+			reverted = append(reverted, change)
+		}
+	}
+	return reverted
+}
+
+// Filter returns a new ThreeWayResult with only the changes the options keep
+func (r *ThreeWayResult) Filter(opts FilterOptions) *ThreeWayResult {
+	if opts.IsEmpty() {
+		return r
+	}
+
+	filtered := &ThreeWayResult{Changes: []ThreeWayChange{}, Warnings: r.Warnings, Truncated: r.Truncated}
+	for _, change := range r.Changes {
+		if opts.matches(change.ResourceDiff) {
+			filtered.Changes = append(filtered.Changes, change)
+		}
+	}
+	return filtered
+}
+
+// CompareThree compares the baseline and current snapshots like Compare and
+// classifies each change against the original snapshot
+func CompareThree(original, baseline, current *snapshot.Snapshot) *ThreeWayResult {
+	return CompareThreeWithOptions(original, baseline, current, CompareOptions{
+		IgnoreAnnotations: filter.DefaultIgnoredAnnotations(),
+	})
+}
+
+// CompareThreeWithOptions compares the baseline and current snapshots like
+// CompareWithOptions, and classifies each change as Reverted when the resource is
+// now as it was in the original snapshot (present and unmodified, or absent in
+// both), or Diverged otherwise
+func CompareThreeWithOptions(original, baseline, current *snapshot.Snapshot, opts CompareOptions) *ThreeWayResult {
+	return ClassifyChanges(original, baseline, CompareWithOptions(baseline, current, opts), opts)
+}
+
+// ClassifyChanges classifies the changes from the baseline snapshot against the
+// original snapshot like CompareThreeWithOptions, for changes that were already
+// compared and narrowed down, e.g. by severity or namespace
+func ClassifyChanges(original, baseline *snapshot.Snapshot, changes *DiffResult, opts CompareOptions) *ThreeWayResult {
+	result := &ThreeWayResult{
+		Changes:   []ThreeWayChange{},
+		Warnings:  append(CompatibilityWarnings(original, baseline), changes.Warnings...),
+		Truncated: changes.Truncated,
+	}
+
+	// Sorted by severity then identity already; keep that order within each type
+	for _, diffs := range [][]ResourceDiff{changes.Added, changes.Removed, changes.Modified} {
+		for _, res := range diffs {
+			result.Changes = append(result.Changes, ThreeWayChange{
+				ResourceDiff: res,
+				VsOriginal:   opts.statusVsOriginal(res, original),
+			})
+		}
+	}
+	return result
+}
+
+// statusVsOriginal reports whether a change left the resource as it was in the
+// original snapshot
+func (o CompareOptions) statusVsOriginal(res ResourceDiff, original *snapshot.Snapshot) OriginalStatus {
+	originalRes, inOriginal := original.Resources[res.Resource.Key()]
+
+	switch res.Type {
+	case Removed:
+		// Gone again, like before it was created
+		if !inOriginal && !original.HasUnknownResources(res.Resource.GroupVersionKind, res.Resource.Namespace) {
+			return Reverted
+		}
+	case Added, Modified:
+		if inOriginal && !o.isModified(originalRes, *res.CurrentResource) {
+			return Reverted
+		}
+	}
+	return Diverged
+}
+
+// OutputThreeWayTable outputs a three-way comparison as a table, with the
+// changes that reverted to the original state highlighted
+func OutputThreeWayTable(result *ThreeWayResult, writer io.Writer) {
+	w := tabwriter.NewWriter(writer, 0, 0, 3, ' ', tabwriter.TabIndent)
+	revertedColor := color.New(color.FgMagenta, color.Bold).SprintFunc()
+
+	// Only Reverted is colored, and tabwriter would count its color codes as
+	// width, so the status is padded here and shares a cell with the severity
+	const statusHeader = "VS ORIGINAL"
+	statusWidth := len(statusHeader)
+	for _, change := range result.Changes {
+		if len(change.VsOriginal) > statusWidth {
+			statusWidth = len(change.VsOriginal)
+		}
+	}
+
+	fmt.Fprintf(w, "OPERATION\tKIND\tNAMESPACE\tNAME\t%-*s   SEVERITY\n", statusWidth, statusHeader)
+	for _, change := range result.Changes {
+		status := fmt.Sprintf("%-*s", statusWidth, change.VsOriginal)
+		if change.VsOriginal == Reverted {
+			status = revertedColor(string(change.VsOriginal)) + status[len(change.VsOriginal):]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s   %s\n",
+			color.New(operationColors[change.Type]).Sprint(change.Type),
+			change.Resource.GroupVersionKind,
+			change.Resource.Namespace,
+			change.Resource.Name,
+			status,
+			severityColor(change.Severity)(change.Severity),
+		)
+	}
+	w.Flush()
+
+	fmt.Fprintf(writer, "\n%d changes, %d of them back to the original state\n", len(result.Changes), len(result.Reverted()))
+}
+
+// OutputThreeWayJSON outputs a three-way comparison as JSON
+func OutputThreeWayJSON(result *ThreeWayResult, writer io.Writer) {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(result)
+}
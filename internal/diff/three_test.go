@@ -0,0 +1,70 @@
+package diff
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+func hashed(hashes map[string]string) *snapshot.Snapshot {
+	snap := &snapshot.Snapshot{Resources: map[string]snapshot.ResourceInfo{}}
+	for key, hash := range hashes {
+		res := snapshot.ResourceInfo{GroupVersionKind: "v1/Secret", Namespace: "payments", Name: key, SpecHash: hash}
+		if key == "settings" {
+			res.GroupVersionKind = "v1/ConfigMap"
+		}
+		snap.Resources[res.Key()] = res
+	}
+	return snap
+}
+
+func TestClassifyNarrowedChanges(t *testing.T) {
+	original := hashed(map[string]string{"db": "a"})
+	baseline := hashed(map[string]string{"api": "x", "db": "b", "tls": "t", "settings": "1"})
+	current := hashed(map[string]string{"api": "y", "db": "a", "tls": "u", "settings": "2"})
+
+	changes := CompareWithOptions(baseline, current, CompareOptions{}).
+		FilterBySeverity(SeverityHigh).
+		LimitPerKind(2)
+	result := ClassifyChanges(original, baseline, changes, CompareOptions{})
+
+	got := map[string]OriginalStatus{}
+	for _, change := range result.Changes {
+		got[change.Resource.Name] = change.VsOriginal
+	}
+	want := map[string]OriginalStatus{"api": Diverged, "db": Reverted}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("classified %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(result.Truncated, map[string]int{"v1/Secret": 1}) {
+		t.Errorf("truncated = %v, want the third Secret", result.Truncated)
+	}
+}
+
+func TestThreeWayTableAlignsColoredStatus(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	original := hashed(map[string]string{"db": "a"})
+	baseline := hashed(map[string]string{"api": "x", "db": "b"})
+	current := hashed(map[string]string{"api": "y", "db": "a"})
+	result := ClassifyChanges(original, baseline, CompareWithOptions(baseline, current, CompareOptions{}), CompareOptions{})
+
+	var out bytes.Buffer
+	OutputThreeWayTable(result, &out)
+
+	// Rows are compared with each other: the colored operations make the header
+	// wider, as in the other tables
+	ansi := regexp.MustCompile("\x1b\\[[0-9;]*m")
+	lines := strings.Split(ansi.ReplaceAllString(out.String(), ""), "\n")
+	diverged, reverted := strings.Index(lines[1], "high"), strings.Index(lines[2], "high")
+	if !strings.Contains(lines[2], "Reverted") || diverged != reverted {
+		t.Errorf("severity not aligned:\n%s", strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,108 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/fatih/color"
+)
+
+// ClusterScopedLabel stands in for the namespace of cluster-scoped resources in the tree
+const ClusterScopedLabel = "(cluster-scoped)"
+
+// TreeNamespace groups the changed resources of one namespace by kind
+type TreeNamespace struct {
+	Namespace string // Empty for cluster-scoped resources
+	Kinds     []TreeKind
+}
+
+// TreeKind holds the changed resources of one resource type (GVK) in a namespace
+type TreeKind struct {
+	Kind      string
+	Resources []ResourceDiff // Sorted by name
+}
+
+// Label returns the namespace as shown in the tree
+func (n TreeNamespace) Label() string {
+	if n.Namespace == "" {
+		return ClusterScopedLabel
+	}
+	return n.Namespace
+}
+
+// Count returns the number of changed resources in the namespace
+func (n TreeNamespace) Count() int {
+	count := 0
+	for _, kind := range n.Kinds {
+		count += len(kind.Resources)
+	}
+	return count
+}
+
+// Tree groups the changes by namespace, then kind, then name. Cluster-scoped
+// resources come first, followed by the namespaces in alphabetical order.
+func (d *DiffResult) Tree() []TreeNamespace {
+	byNamespace := map[string]map[string][]ResourceDiff{}
+	for _, group := range [][]ResourceDiff{d.Added, d.Removed, d.Modified} {
+		for _, res := range group {
+			namespace, kind := res.Resource.Namespace, res.Resource.GroupVersionKind
+			if byNamespace[namespace] == nil {
+				byNamespace[namespace] = map[string][]ResourceDiff{}
+			}
+			byNamespace[namespace][kind] = append(byNamespace[namespace][kind], res)
+		}
+	}
+
+	tree := make([]TreeNamespace, 0, len(byNamespace))
+	for namespace, byKind := range byNamespace {
+		node := TreeNamespace{Namespace: namespace}
+		for kind, resources := range byKind {
+			sort.SliceStable(resources, func(i, j int) bool {
+				return resources[i].Resource.Name < resources[j].Resource.Name
+			})
+			node.Kinds = append(node.Kinds, TreeKind{Kind: kind, Resources: resources})
+		}
+		sort.Slice(node.Kinds, func(i, j int) bool { return node.Kinds[i].Kind < node.Kinds[j].Kind })
+		tree = append(tree, node)
+	}
+	sort.Slice(tree, func(i, j int) bool { return tree[i].Namespace < tree[j].Namespace })
+	return tree
+}
+
+// TreeMarker returns the marker shown before a resource of the given change type
+func TreeMarker(op DiffType) string {
+	switch op {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+// OutputTree outputs the diff as a tree grouped by namespace, kind and name, with
+// a colored +/-/~ marker for added, removed and modified resources
+func OutputTree(diff *DiffResult, writer io.Writer) {
+	colors := map[DiffType]func(a ...interface{}) string{
+		Added:    color.New(color.FgGreen).SprintFunc(),
+		Removed:  color.New(color.FgRed).SprintFunc(),
+		Modified: color.New(color.FgYellow).SprintFunc(),
+	}
+	bold := color.New(color.Bold).SprintFunc()
+
+	for _, namespace := range diff.Tree() {
+		fmt.Fprintf(writer, "%s (%d)\n", bold(namespace.Label()), namespace.Count())
+		for _, kind := range namespace.Kinds {
+			fmt.Fprintf(writer, "  %s (%d)\n", kind.Kind, len(kind.Resources))
+			for _, res := range kind.Resources {
+				fmt.Fprintf(writer, "    %s %s\n", colors[res.Type](TreeMarker(res.Type)), res.Resource.Name)
+			}
+		}
+	}
+
+	for _, kind := range diff.TruncatedKinds() {
+		fmt.Fprintf(writer, "… and %d more %s\n", diff.Truncated[kind], kind)
+	}
+}
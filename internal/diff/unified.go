@@ -0,0 +1,178 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// DefaultContextLines is the number of unchanged lines shown around each change
+// in unified diffs
+const DefaultContextLines = 3
+
+// lineOp is a single line of a line diff: ' ' unchanged, '-' removed or '+' added
+type lineOp struct {
+	op   byte
+	text string
+}
+
+// diffLines computes a line diff between two manifests split into lines
+func diffLines(oldLines, newLines []string) []lineOp {
+	join := func(lines []string) string {
+		if len(lines) == 0 {
+			return ""
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	ops := []lineOp{}
+	for _, d := range lineDiffs(join(oldLines), join(newLines)) {
+		op := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(d.Text, "\n"), "\n") {
+			ops = append(ops, lineOp{op: op, text: line})
+		}
+	}
+	return ops
+}
+
+// UnifiedDiff renders a unified diff between two manifests given as lines, with
+// contextLines unchanged lines around each change. It returns the hunks, each
+// starting with an "@@ -a,b +c,d @@" header, or nothing if the lines are equal.
+func UnifiedDiff(oldLines, newLines []string, contextLines int) []string {
+	if contextLines < 0 {
+		contextLines = 0
+	}
+
+	ops := diffLines(oldLines, newLines)
+
+	// Line numbers (1-based) of each op in the old and new manifest
+	oldNo := make([]int, len(ops))
+	newNo := make([]int, len(ops))
+	o, n := 1, 1
+	for i, op := range ops {
+		oldNo[i], newNo[i] = o, n
+		if op.op != '+' {
+			o++
+		}
+		if op.op != '-' {
+			n++
+		}
+	}
+
+	var out []string
+	for i := 0; i < len(ops); {
+		if ops[i].op == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk while the next change is close enough to share context
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].op != ' ' {
+				end = j
+			} else if j-end > 2*contextLines {
+				break
+			}
+		}
+		stop := end + contextLines + 1
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		oldCount, newCount := 0, 0
+		lines := []string{}
+		for _, op := range ops[start:stop] {
+			if op.op != '+' {
+				oldCount++
+			}
+			if op.op != '-' {
+				newCount++
+			}
+			lines = append(lines, string(op.op)+op.text)
+		}
+
+		oldStart, newStart := oldNo[start], newNo[start]
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+
+		out = append(out, fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, oldCount, newStart, newCount))
+		out = append(out, lines...)
+		i = stop
+	}
+
+	return out
+}
+
+// manifestLines splits a manifest into lines, ignoring the trailing newline
+func manifestLines(manifest string) []string {
+	if manifest == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(manifest, "\n"), "\n")
+}
+
+// OutputUnified outputs the diff as unified diffs of the resource manifests, with
+// contextLines unchanged lines around each change
+func OutputUnified(diff *DiffResult, writer io.Writer, contextLines int) {
+	headerColor := color.New(color.Bold).SprintFunc()
+	hunkColor := color.New(color.FgCyan).SprintFunc()
+	addColor := color.New(color.FgGreen).SprintFunc()
+	removeColor := color.New(color.FgRed).SprintFunc()
+
+	for _, diffs := range [][]ResourceDiff{diff.Added, diff.Removed, diff.Modified} {
+		for _, res := range diffs {
+			path := fmt.Sprintf("%s/%s/%s", res.Resource.GroupVersionKind, res.Resource.Namespace, res.Resource.Name)
+
+			oldName, newName := "baseline/"+path, "current/"+path
+			var oldManifest, newManifest string
+			if res.BaselineResource != nil {
+				oldManifest = res.BaselineResource.Manifest
+			} else {
+				oldName = "/dev/null"
+			}
+			if res.CurrentResource != nil {
+				newManifest = res.CurrentResource.Manifest
+			} else {
+				newName = "/dev/null"
+			}
+
+			fmt.Fprintln(writer, headerColor("--- "+oldName))
+			fmt.Fprintln(writer, headerColor("+++ "+newName))
+
+			hunks := UnifiedDiff(manifestLines(oldManifest), manifestLines(newManifest), contextLines)
+			if len(hunks) == 0 {
+				// e.g. only the resourceVersion changed, which isn't in the manifest diff
+				fmt.Fprintf(writer, "# %s: manifests are identical\n", res.Type)
+			}
+			for _, line := range hunks {
+				switch {
+				case strings.HasPrefix(line, "@@"):
+					line = hunkColor(line)
+				case strings.HasPrefix(line, "+"):
+					line = addColor(line)
+				case strings.HasPrefix(line, "-"):
+					line = removeColor(line)
+				}
+				fmt.Fprintln(writer, line)
+			}
+		}
+	}
+}
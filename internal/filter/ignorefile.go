@@ -0,0 +1,54 @@
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// IgnoreFileName is the file in the working directory listing resource types to
+// exclude, one glob per line (see GlobToRegex). Lines starting with '#' are comments.
+const IgnoreFileName = ".k8srdiffignore"
+
+// LoadIgnoreFile reads the exclusion globs from an ignore file. A missing file
+// is not an error and yields no globs.
+func LoadIgnoreFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	var globs []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		globs = append(globs, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	return globs, nil
+}
+
+// AppendToIgnoreFile adds a glob to an ignore file, creating the file if needed
+func AppendToIgnoreFile(path, glob string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintln(file, glob); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
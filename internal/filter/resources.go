@@ -1,15 +1,20 @@
 package filter
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
 
 // ResourceFilter provides functionality for filtering Kubernetes resources
 type ResourceFilter struct {
-	IncludePatterns []string
-	ExcludePatterns []string
-	compiledFilter  *regexp.Regexp
+	IncludePatterns   []string
+	ExcludePatterns   []string
+	APIGroups         []string // Allowlist of API groups to discover (empty for all)
+	ExcludeNamespaces []string // Namespaces whose resources are dropped after listing
+	compiledFilter    *regexp.Regexp
+	compiledExcludes  []*regexp.Regexp
+	compiledIncludes  []*regexp.Regexp
 }
 
 // DefaultNoisyResources returns a list of regex patterns for API resources
@@ -62,13 +67,33 @@ func DefaultNoisyResources() []string {
 	}
 }
 
+// DefaultIgnoredAnnotations returns annotations that tools like kubectl, ArgoCD
+// and Flux rewrite constantly, so changes to them don't mark a resource modified
+func DefaultIgnoredAnnotations() []string {
+	return []string{
+		"kubectl.kubernetes.io/last-applied-configuration",
+		"argocd.argoproj.io/tracking-id",
+		"argocd.argoproj.io/refresh",
+		"reconcile.fluxcd.io/requestedAt",
+		"kustomize.toolkit.fluxcd.io/checksum",
+	}
+}
+
+// DefaultStrippedAnnotations returns annotations dropped from captured manifests
+// because they bloat snapshots without adding information (last-applied-configuration
+// repeats the whole object as a JSON string)
+func DefaultStrippedAnnotations() []string {
+	return []string{
+		"kubectl.kubernetes.io/last-applied-configuration",
+	}
+}
+
 // CommonSystemNamespaces returns a list of system namespaces that might be excluded
 func CommonSystemNamespaces() []string {
 	return []string{
 		"kube-system",
 		"kube-public",
 		"kube-node-lease",
-		"default",
 		"flux-system",
 	}
 }
@@ -93,6 +118,33 @@ func (rf *ResourceFilter) WithExcludes(patterns []string) *ResourceFilter {
 	return rf
 }
 
+// WithExcludeGlobs adds glob-style exclude patterns such as "*/Event" or "apps/v1/*"
+func (rf *ResourceFilter) WithExcludeGlobs(globs []string) *ResourceFilter {
+	for _, glob := range globs {
+		rf.ExcludePatterns = append(rf.ExcludePatterns, GlobToRegex(glob))
+	}
+	return rf
+}
+
+// GlobToRegex translates a glob into an anchored regex matching whole resource
+// types. '*' matches any run of characters (including '/') and '?' a single one.
+func GlobToRegex(glob string) string {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			pattern.WriteString(".*")
+		case '?':
+			pattern.WriteString(".")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	pattern.WriteString("$")
+	return pattern.String()
+}
+
 // WithIncludes adds specific include patterns (resources that should be included
 // even if they match an exclude pattern)
 func (rf *ResourceFilter) WithIncludes(patterns []string) *ResourceFilter {
@@ -100,8 +152,69 @@ func (rf *ResourceFilter) WithIncludes(patterns []string) *ResourceFilter {
 	return rf
 }
 
+// WithIncludedGroups keeps every kind in the given API groups, e.g. the CRDs of
+// your own operators, even if it matches an exclude pattern
+func (rf *ResourceFilter) WithIncludedGroups(groups []string) *ResourceFilter {
+	for _, group := range groups {
+		rf.IncludePatterns = append(rf.IncludePatterns, "^"+regexp.QuoteMeta(group)+"/")
+	}
+	return rf
+}
+
+// WithAPIGroups restricts discovery to resources in the given API groups. The
+// core group can be given as "core" or ""
+func (rf *ResourceFilter) WithAPIGroups(groups []string) *ResourceFilter {
+	rf.APIGroups = append(rf.APIGroups, groups...)
+	return rf
+}
+
+// WithExcludedNamespaces drops resources in the given namespaces
+func (rf *ResourceFilter) WithExcludedNamespaces(namespaces []string) *ResourceFilter {
+	rf.ExcludeNamespaces = append(rf.ExcludeNamespaces, namespaces...)
+	return rf
+}
+
+// ShouldExcludeNamespace determines if resources in a namespace should be dropped.
+// Cluster-scoped resources (empty namespace) are never excluded.
+func (rf *ResourceFilter) ShouldExcludeNamespace(namespace string) bool {
+	if namespace == "" {
+		return false
+	}
+
+	for _, excluded := range rf.ExcludeNamespaces {
+		if excluded == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsGroup reports whether resources in the API group should be discovered
+func (rf *ResourceFilter) AllowsGroup(group string) bool {
+	if len(rf.APIGroups) == 0 {
+		return true
+	}
+
+	for _, allowed := range rf.APIGroups {
+		if allowed == group || (allowed == "core" && group == "") {
+			return true
+		}
+	}
+	return false
+}
+
 // Compile prepares the filter for use
 func (rf *ResourceFilter) Compile() error {
+	// Compile include patterns so invalid ones are reported instead of panicking later
+	rf.compiledIncludes = nil
+	for _, include := range rf.IncludePatterns {
+		compiled, err := regexp.Compile(include)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %q: %v", include, err)
+		}
+		rf.compiledIncludes = append(rf.compiledIncludes, compiled)
+	}
+
 	// If no exclude patterns, there's nothing to compile
 	if len(rf.ExcludePatterns) == 0 {
 		rf.compiledFilter = nil
@@ -116,6 +229,16 @@ func (rf *ResourceFilter) Compile() error {
 	}
 	
 	rf.compiledFilter = compiled
+
+	// Also keep each pattern on its own, to tell which one excluded a type
+	rf.compiledExcludes = nil
+	for _, exclude := range rf.ExcludePatterns {
+		compiled, err := regexp.Compile(exclude)
+		if err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %v", exclude, err)
+		}
+		rf.compiledExcludes = append(rf.compiledExcludes, compiled)
+	}
 	return nil
 }
 
@@ -128,8 +251,8 @@ func (rf *ResourceFilter) ShouldExclude(resourceType string) bool {
 	// Check against exclude patterns
 	if rf.compiledFilter.MatchString(resourceType) {
 		// Check if it matches any include patterns (which override excludes)
-		for _, include := range rf.IncludePatterns {
-			if regexp.MustCompile(include).MatchString(resourceType) {
+		for _, include := range rf.compiledIncludes {
+			if include.MatchString(resourceType) {
 				return false
 			}
 		}
@@ -138,3 +261,14 @@ func (rf *ResourceFilter) ShouldExclude(resourceType string) bool {
 	
 	return false
 }
+
+// MatchingExclude returns the first exclude pattern that matches the resource
+// type, or "" if none does. Include patterns aren't considered.
+func (rf *ResourceFilter) MatchingExclude(resourceType string) string {
+	for i, exclude := range rf.compiledExcludes {
+		if exclude.MatchString(resourceType) {
+			return rf.ExcludePatterns[i]
+		}
+	}
+	return ""
+}
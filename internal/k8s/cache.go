@@ -0,0 +1,147 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Cache keeps informer-backed copies of a set of resource types, so their current
+// state can be read repeatedly without relisting everything from the API server.
+// After the initial list, the informers only receive watch events.
+type Cache struct {
+	factories []dynamicinformer.DynamicSharedInformerFactory
+	informers map[string]informers.GenericInformer // By resource type
+	opts      ListOptions
+	changes   chan struct{}
+}
+
+// NewCache creates informers for the resource types, limited to namespace for
+// namespaced types (empty for all namespaces). Only opts.NamePattern is applied;
+// field selectors aren't supported because a rejected one would stop the informer
+// from ever syncing. Call Start before reading from the cache.
+func (c *Client) NewCache(resourceTypes []string, namespace string, opts ListOptions) (*Cache, error) {
+	// Cluster-scoped types can't be watched through a namespaced factory
+	namespaced := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.dynamicClient, 0, namespace, nil)
+	clusterWide := namespaced
+	if namespace != "" {
+		clusterWide = dynamicinformer.NewDynamicSharedInformerFactory(c.dynamicClient, 0)
+	}
+
+	informerCache := &Cache{
+		factories: []dynamicinformer.DynamicSharedInformerFactory{namespaced},
+		informers: make(map[string]informers.GenericInformer),
+		opts:      opts,
+		changes:   make(chan struct{}, 1),
+	}
+	if clusterWide != namespaced {
+		informerCache.factories = append(informerCache.factories, clusterWide)
+	}
+
+	for _, resourceType := range resourceTypes {
+		gvr, resource, err := c.resolveResource(resourceType)
+		if err != nil {
+			return nil, err
+		}
+
+		factory := clusterWide
+		if resource.Namespaced {
+			factory = namespaced
+		}
+
+		informer := factory.ForResource(gvr)
+		informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(interface{}) { informerCache.notify() },
+			UpdateFunc: func(oldObj, newObj interface{}) { informerCache.notifyUpdate(oldObj, newObj) },
+			DeleteFunc: func(interface{}) { informerCache.notify() },
+		})
+		informerCache.informers[resourceType] = informer
+	}
+
+	return informerCache, nil
+}
+
+// notify signals a change without blocking; pending signals are coalesced
+func (c *Cache) notify() {
+	select {
+	case c.changes <- struct{}{}:
+	default:
+	}
+}
+
+// notifyUpdate signals an update unless it's a periodic resync of an unchanged object
+func (c *Cache) notifyUpdate(oldObj, newObj interface{}) {
+	oldMeta, oldOK := oldObj.(metav1.Object)
+	newMeta, newOK := newObj.(metav1.Object)
+	if oldOK && newOK && oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+		return
+	}
+	c.notify()
+}
+
+// Start runs the informers until ctx is done and waits up to syncTimeout for their
+// initial lists. It returns the resource types that didn't sync in time, e.g.
+// because listing them is forbidden; those stay empty in the cache.
+func (c *Cache) Start(ctx context.Context, syncTimeout time.Duration) []string {
+	for _, factory := range c.factories {
+		factory.Start(ctx.Done())
+	}
+
+	syncCtx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+
+	var unsynced []string
+	for resourceType, informer := range c.informers {
+		if !cache.WaitForCacheSync(syncCtx.Done(), informer.Informer().HasSynced) {
+			unsynced = append(unsynced, resourceType)
+		}
+	}
+
+	// The initial lists shouldn't count as changes
+	select {
+	case <-c.changes:
+	default:
+	}
+	return unsynced
+}
+
+// Changes returns a channel that receives a value after resources were added,
+// updated or deleted. Several events in a row may be delivered as one.
+func (c *Cache) Changes() <-chan struct{} {
+	return c.changes
+}
+
+// ListResources returns the cached resources of a type, like Client.ListResources
+func (c *Cache) ListResources(resourceType string) ([]Resource, error) {
+	informer, ok := c.informers[resourceType]
+	if !ok {
+		return nil, fmt.Errorf("resource type not cached: %s", resourceType)
+	}
+
+	objects, err := informer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cached resources: %v", err)
+	}
+
+	var resources []Resource
+	for _, object := range objects {
+		item, ok := object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if c.opts.NamePattern != nil && !c.opts.NamePattern.MatchString(item.GetName()) {
+			continue
+		}
+
+		// Objects in the cache are shared, so never hand out their maps
+		resources = append(resources, toResource(item.DeepCopy()))
+	}
+	return resources, nil
+}
@@ -5,16 +5,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/winson-sou/k8s-rdiff/internal/filter"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -22,49 +27,132 @@ import (
 type Resource struct {
 	ApiVersion         string                 `json:"apiVersion"`
 	Kind               string                 `json:"kind"`
+	Type               string                 `json:"-"` // Top-level type field, e.g. of Secrets
 	Metadata           Metadata               `json:"metadata"`
 	Spec               map[string]interface{} `json:"spec,omitempty"`
+	Data               map[string]interface{} `json:"data,omitempty"`       // ConfigMaps and Secrets
+	BinaryData         map[string]interface{} `json:"binaryData,omitempty"` // ConfigMaps
 	Status             map[string]interface{} `json:"status,omitempty"`
 	AdditionalData     map[string]interface{} `json:"-"`
+	Object             map[string]interface{} `json:"-"` // The whole object as listed
 }
 
 // Metadata contains resource metadata
 type Metadata struct {
-	Name              string            `json:"name"`
-	Namespace         string            `json:"namespace,omitempty"`
-	UID               string            `json:"uid"`
-	ResourceVersion   string            `json:"resourceVersion"`
-	CreationTimestamp string            `json:"creationTimestamp"`
-	Labels            map[string]string `json:"labels,omitempty"`
-	Annotations       map[string]string `json:"annotations,omitempty"`
+	Name              string                  `json:"name"`
+	Namespace         string                  `json:"namespace,omitempty"`
+	UID               string                  `json:"uid"`
+	ResourceVersion   string                  `json:"resourceVersion"`
+	Generation        int64                   `json:"-"` // Bumped by the API server on spec changes
+	CreationTimestamp time.Time               `json:"creationTimestamp"`
+	Labels            map[string]string       `json:"labels,omitempty"`
+	Annotations       map[string]string       `json:"annotations,omitempty"`
+	OwnerReferences   []metav1.OwnerReference `json:"-"`
+}
+
+// ListOptions narrows down the resources returned by ListResources
+type ListOptions struct {
+	// NamePattern keeps only resources whose metadata.name matches. It is applied
+	// after listing because name isn't a server-side field selector for every type.
+	NamePattern *regexp.Regexp
+
+	// FieldSelector is passed to the API server. Only some fields are selectable per
+	// type; types that reject it are listed without a selector instead.
+	FieldSelector string
+
+	// CachedReads lists with resourceVersion "0", which the API server answers from
+	// its watch cache instead of a quorum read of etcd. It's cheaper on busy
+	// clusters, but the result may be slightly stale.
+	CachedReads bool
+}
+
+// metaListOptions returns the list options sent to the API server
+func (o ListOptions) metaListOptions() metav1.ListOptions {
+	listOpts := metav1.ListOptions{FieldSelector: o.FieldSelector}
+	if o.CachedReads {
+		listOpts.ResourceVersion = "0"
+	}
+	return listOpts
 }
 
 // Client is a client for interacting with Kubernetes
 type Client struct {
 	dynamicClient   dynamic.Interface
 	discoveryClient discovery.DiscoveryInterface
+	protobufConfig  *rest.Config // Set when built-in types should be listed as protobuf
 }
 
-// NewClient creates a new Kubernetes client
-func NewClient(kubeconfigPath string) (*Client, error) {
-	// Use the provided kubeconfig path or determine the default
-	if kubeconfigPath == "" {
-		kubeconfigEnv := os.Getenv("KUBECONFIG")
-		if kubeconfigEnv != "" {
-			kubeconfigPath = kubeconfigEnv
-		} else {
-			home, err := os.UserHomeDir()
-			if err != nil {
-				return nil, fmt.Errorf("failed to get user home directory: %v", err)
-			}
-			kubeconfigPath = filepath.Join(home, ".kube", "config")
+// Default client-side rate limits. client-go's own defaults (5 QPS, burst 10)
+// throttle a full capture of a large cluster, which lists every resource type.
+const (
+	DefaultQPS   = 50
+	DefaultBurst = 100
+)
+
+// ClientOptions controls how the kubeconfig is loaded
+type ClientOptions struct {
+	// KubeconfigPath is the kubeconfig file to use. When empty, the files listed
+	// in KUBECONFIG are merged, falling back to ~/.kube/config.
+	KubeconfigPath string
+
+	// Context overrides the kubeconfig's current context
+	Context string
+
+	// As, AsGroups and AsUID impersonate another user, as kubectl's --as,
+	// --as-group and --as-uid do, so the capture sees what that identity can see
+	As       string
+	AsGroups []string
+	AsUID    string
+
+	// QPS and Burst set the client-side rate limit (DefaultQPS and DefaultBurst when 0)
+	QPS   float32
+	Burst int
+
+	// Protobuf lists built-in resource types using the protobuf encoding, which is
+	// faster and smaller than JSON. Other types (e.g. CRDs) are listed as JSON.
+	Protobuf bool
+}
+
+// LoadConfig loads the REST config described by the options, merging kubeconfig
+// files the same way kubectl does
+func LoadConfig(opts ClientOptions) (*rest.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if opts.KubeconfigPath != "" {
+		loadingRules.ExplicitPath = opts.KubeconfigPath
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: opts.Context}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+	if err != nil {
+		if opts.KubeconfigPath != "" {
+			return nil, fmt.Errorf("failed to load kubeconfig %s: %v", opts.KubeconfigPath, err)
+		}
+		return nil, fmt.Errorf("failed to load kubeconfig: %v", err)
+	}
+
+	config.QPS, config.Burst = opts.QPS, opts.Burst
+	if config.QPS == 0 {
+		config.QPS = DefaultQPS
+	}
+	if config.Burst == 0 {
+		config.Burst = DefaultBurst
+	}
+
+	if opts.As != "" || len(opts.AsGroups) > 0 || opts.AsUID != "" {
+		config.Impersonate = rest.ImpersonationConfig{
+			UserName: opts.As,
+			Groups:   opts.AsGroups,
+			UID:      opts.AsUID,
 		}
 	}
+	return config, nil
+}
 
-	// Build configuration from kubeconfig file
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+// NewClient creates a new Kubernetes client
+func NewClient(opts ClientOptions) (*Client, error) {
+	config, err := LoadConfig(opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build config from kubeconfig %s: %v", kubeconfigPath, err)
+		return nil, err
 	}
 
 	// Create discovery client
@@ -79,42 +167,203 @@ func NewClient(kubeconfigPath string) (*Client, error) {
 		return nil, fmt.Errorf("failed to create dynamic client: %v", err)
 	}
 
+	client := &Client{
+		dynamicClient:   dynamicClient,
+		discoveryClient: discoveryClient,
+	}
+	if opts.Protobuf {
+		client.protobufConfig = rest.CopyConfig(config)
+	}
+	return client, nil
+}
+
+// NewClientFromInterfaces returns a client using the given dynamic and discovery
+// clients, e.g. fakes in tests
+func NewClientFromInterfaces(dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface) *Client {
 	return &Client{
 		dynamicClient:   dynamicClient,
 		discoveryClient: discoveryClient,
-	}, nil
+	}
 }
 
-// DiscoverResources discovers all API resources available in the cluster
-func (c *Client) DiscoverResources(resourceFilter *filter.ResourceFilter) ([]string, error) {
-	// Get server API resources
-	_, apiResources, err := c.discoveryClient.ServerGroupsAndResources()
+// ResolveKind resolves a single kind, given as a Kind ("Deployment"), a resource
+// name ("deployments") or a resource qualified by its group ("deployments.apps"),
+// to a resource type in the form used by DiscoverResources. Only the preferred
+// version of each candidate group is queried, stopping at the first match, so this
+// is much cheaper than full discovery.
+func (c *Client) ResolveKind(kind string) (string, error) {
+	name, group := kind, ""
+	qualified := false
+	if i := strings.Index(kind, "."); i >= 0 {
+		name, group = kind[:i], kind[i+1:]
+		qualified = true
+	}
+
+	groups, err := c.discoveryClient.ServerGroups()
+	if err != nil {
+		return "", fmt.Errorf("failed to discover API groups: %v", err)
+	}
+
+	for _, g := range groups.Groups {
+		if qualified && g.Name != group {
+			continue
+		}
+
+		gv := g.PreferredVersion.GroupVersion
+		resourceList, err := c.discoveryClient.ServerResourcesForGroupVersion(gv)
+		if err != nil {
+			continue
+		}
+
+		for _, r := range resourceList.APIResources {
+			// Skip subresources like deployments/status
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+
+			if strings.EqualFold(r.Kind, name) || r.Name == strings.ToLower(name) ||
+				r.SingularName == strings.ToLower(name) || containsString(r.ShortNames, strings.ToLower(name)) {
+				if !containsString(r.Verbs, "list") {
+					return "", fmt.Errorf("kind %q cannot be listed", kind)
+				}
+				return fmt.Sprintf("%s/%s", gv, r.Kind), nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("kind %q not found in the cluster", kind)
+}
+
+// ServerVersion returns the Kubernetes version of the API server (e.g. v1.28.2)
+func (c *Client) ServerVersion() (string, error) {
+	info, err := c.discoveryClient.ServerVersion()
+	if err != nil {
+		return "", fmt.Errorf("failed to get server version: %v", err)
+	}
+	return info.GitVersion, nil
+}
+
+// CountNodes returns the number of nodes in the cluster, or 0 if it's unknown,
+// e.g. without permission to list nodes. Only one node is listed and the rest
+// are counted from the remaining item count, which stays cheap on large clusters.
+func (c *Client) CountNodes(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	nodes := schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+	list, err := c.dynamicClient.Resource(nodes).List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		if apierrors.IsForbidden(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	return nodeCount(list), nil
+}
+
+// nodeCount counts the nodes of a list cut off after its first page: the nodes on
+// it and the remaining item count, or 0 if more remain without a count
+func nodeCount(list *unstructured.UnstructuredList) int {
+	count := len(list.Items)
+	if remaining := list.GetRemainingItemCount(); remaining != nil {
+		return count + int(*remaining)
+	}
+	if list.GetContinue() != "" {
+		return 0
+	}
+	return count
+}
+
+// IsPartialDiscoveryError reports whether err means discovery failed for some API groups only
+func IsPartialDiscoveryError(err error) bool {
+	return discovery.IsGroupDiscoveryFailedError(err)
+}
+
+// serverResources fetches the API resource lists from the server. When the filter
+// has an API group allowlist only the matching groups are queried, which avoids
+// a discovery round trip per group on clusters with many CRDs
+func (c *Client) serverResources(resourceFilter *filter.ResourceFilter) ([]*metav1.APIResourceList, error) {
+	if resourceFilter == nil || len(resourceFilter.APIGroups) == 0 {
+		_, apiResources, err := c.discoveryClient.ServerGroupsAndResources()
+		return apiResources, err
+	}
+
+	groups, err := c.discoveryClient.ServerGroups()
 	if err != nil {
-		// Handle partial discovery errors
-		if !discovery.IsGroupDiscoveryFailedError(err) {
-			return nil, fmt.Errorf("failed to discover API resources: %v", err)
+		return nil, err
+	}
+
+	apiResources := []*metav1.APIResourceList{}
+	failedGroups := map[schema.GroupVersion]error{}
+	for _, group := range groups.Groups {
+		if !resourceFilter.AllowsGroup(group.Name) {
+			continue
 		}
-		// Continue with partial results if some groups failed
-		fmt.Fprintf(os.Stderr, "Warning: partial discovery failure: %v\n", err)
+
+		for _, version := range group.Versions {
+			resourceList, err := c.discoveryClient.ServerResourcesForGroupVersion(version.GroupVersion)
+			if err != nil {
+				gv, _ := schema.ParseGroupVersion(version.GroupVersion)
+				failedGroups[gv] = err
+				continue
+			}
+			apiResources = append(apiResources, resourceList)
+		}
+	}
+
+	if len(failedGroups) > 0 {
+		return apiResources, &discovery.ErrGroupDiscoveryFailed{Groups: failedGroups}
+	}
+	return apiResources, nil
+}
+
+// ExcludedType is a discovered resource type that won't be captured, and why
+type ExcludedType struct {
+	ResourceType string
+	Reason       string
+	Filtered     bool // Excluded by the resource filter, rather than unlistable
+}
+
+// DiscoverResources discovers all API resources available in the cluster, along
+// with the resource types left out because they can't be listed or match the
+// filter. If only some API groups could be discovered, the resource types found
+// are returned along with an error for which IsPartialDiscoveryError reports true.
+func (c *Client) DiscoverResources(resourceFilter *filter.ResourceFilter) ([]string, []ExcludedType, error) {
+	// Get server API resources
+	apiResources, discoveryErr := c.serverResources(resourceFilter)
+	if discoveryErr != nil && !discovery.IsGroupDiscoveryFailedError(discoveryErr) {
+		return nil, nil, fmt.Errorf("failed to discover API resources: %v", discoveryErr)
 	}
 	
 	resourceTypes := []string{}
+	excluded := []ExcludedType{}
 	
 	// Process each resource
 	for _, resourceList := range apiResources {
 		gv := resourceList.GroupVersion
 		for _, r := range resourceList.APIResources {
-			// Skip resources that can't be listed
-			if !containsString(r.Verbs, "list") {
+			// Subresources such as deployments/scale are part of their resource
+			if strings.Contains(r.Name, "/") {
 				continue
 			}
-			
+
 			// Create resource type string
 			resourceType := fmt.Sprintf("%s/%s", gv, r.Kind)
 			
+			// Skip resources that can't be listed
+			if !containsString(r.Verbs, "list") {
+				excluded = append(excluded, ExcludedType{ResourceType: resourceType, Reason: "can't be listed"})
+				continue
+			}
+			
 			// Skip resources that should be excluded by our filter
 			if resourceFilter != nil && resourceFilter.ShouldExclude(resourceType) {
-				fmt.Fprintf(os.Stderr, "Ignoring resource type: %s (matched exclusion pattern)\n", resourceType)
+				excluded = append(excluded, ExcludedType{
+					ResourceType: resourceType,
+					Reason:       fmt.Sprintf("matched exclusion pattern %q", resourceFilter.MatchingExclude(resourceType)),
+					Filtered:     true,
+				})
 				continue
 			}
 			
@@ -122,29 +371,53 @@ func (c *Client) DiscoverResources(resourceFilter *filter.ResourceFilter) ([]str
 		}
 	}
 	
-	return resourceTypes, nil
+	// If some groups failed, the caller decides whether the rest is good enough
+	return resourceTypes, excluded, discoveryErr
 }
 
-// ListResources lists all resources of the specified type in the given namespace
-func (c *Client) ListResources(resourceType string, namespace string) ([]Resource, error) {
+// CheckResourceType checks that the cluster serves a resource type
+// ("apps/v1/Deployment") and that it can be listed, querying only its group version
+func (c *Client) CheckResourceType(resourceType string) error {
+	_, resource, err := c.resolveResource(resourceType)
+	if err != nil {
+		return fmt.Errorf("%s: %v", resourceType, err)
+	}
+	if !containsString(resource.Verbs, "list") {
+		return fmt.Errorf("%s: cannot be listed", resourceType)
+	}
+	return nil
+}
+
+// IsNamespaced reports whether a resource type ("apps/v1/Deployment") is namespaced
+func (c *Client) IsNamespaced(resourceType string) (bool, error) {
+	_, resource, err := c.resolveResource(resourceType)
+	if err != nil {
+		return false, err
+	}
+	return resource.Namespaced, nil
+}
+
+// resolveResource looks up the resource of a resource type ("apps/v1/Deployment")
+// and its API resource details
+func (c *Client) resolveResource(resourceType string) (schema.GroupVersionResource, metav1.APIResource, error) {
 	// Parse resource type to get group, version, and kind
 	parts := strings.Split(resourceType, "/")
 	if len(parts) < 2 {
-		return nil, fmt.Errorf("invalid resource type format: %s", resourceType)
+		return schema.GroupVersionResource{}, metav1.APIResource{}, fmt.Errorf("invalid resource type format: %s", resourceType)
 	}
-	
+
 	kind := parts[len(parts)-1]
 	groupVersion := strings.Join(parts[:len(parts)-1], "/")
-	
+
 	// Find the resource in the API server
 	resourceList, err := c.discoveryClient.ServerResourcesForGroupVersion(groupVersion)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get resources for %s: %v", groupVersion, err)
+		return schema.GroupVersionResource{}, metav1.APIResource{}, fmt.Errorf("failed to get resources for %s: %v", groupVersion, err)
 	}
-	
+
 	var resource metav1.APIResource
 	var foundResource bool
-	
+
 	for _, r := range resourceList.APIResources {
 		if r.Kind == kind {
 			resource = r
@@ -152,75 +425,115 @@ func (c *Client) ListResources(resourceType string, namespace string) ([]Resourc
 			break
 		}
 	}
-	
+
 	if !foundResource {
-		return nil, fmt.Errorf("resource not found: %s", resourceType)
+		return schema.GroupVersionResource{}, metav1.APIResource{}, fmt.Errorf("resource not found: %s", resourceType)
 	}
-	
+
 	// Create group version resource
 	gv, err := schema.ParseGroupVersion(groupVersion)
 	if err != nil {
-		return nil, fmt.Errorf("invalid group version: %s", groupVersion)
+		return schema.GroupVersionResource{}, metav1.APIResource{}, fmt.Errorf("invalid group version: %s", groupVersion)
 	}
-	
+
 	gvr := schema.GroupVersionResource{
 		Group:    gv.Group,
 		Version:  gv.Version,
 		Resource: resource.Name,
 	}
-	
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	return gvr, resource, nil
+}
+
+// listAttemptTimeout bounds each list request ListResources makes, so a slow
+// protobuf attempt doesn't leave the JSON retry without time
+const listAttemptTimeout = 30 * time.Second
+
+// withListTimeout runs one list request with its own listAttemptTimeout
+func withListTimeout(ctx context.Context, list func(ctx context.Context) (*unstructured.UnstructuredList, error)) (*unstructured.UnstructuredList, error) {
+	ctx, cancel := context.WithTimeout(ctx, listAttemptTimeout)
 	defer cancel()
-	
-	// List the resources
-	var list *unstructured.UnstructuredList
+	return list(ctx)
+}
+
+// ListResources lists all resources of the specified type in the given namespace
+func (c *Client) ListResources(ctx context.Context, resourceType string, namespace string, opts ListOptions) ([]Resource, error) {
+	gvr, resource, err := c.resolveResource(resourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pick the endpoint to list from
+	var resourceClient dynamic.ResourceInterface
 	if resource.Namespaced && namespace != "" {
-		list, err = c.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
-	} else if resource.Namespaced {
-		list, err = c.dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+		resourceClient = c.dynamicClient.Resource(gvr).Namespace(namespace)
 	} else {
-		// Cluster-scoped resources
-		list, err = c.dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+		// All namespaces or cluster-scoped resources
+		resourceClient = c.dynamicClient.Resource(gvr)
 	}
-	
-	if err != nil {
-		return nil, fmt.Errorf("failed to list resources: %v", err)
+
+	// List the resources
+	listOpts := opts.metaListOptions()
+	var list *unstructured.UnstructuredList
+	gvk := gvr.GroupVersion().WithKind(resource.Kind)
+	if c.protobufConfig != nil && scheme.Scheme.Recognizes(gvk) {
+		listNamespace := ""
+		if resource.Namespaced {
+			listNamespace = namespace
+		}
+
+		// Anything going wrong here (e.g. an aggregated API without protobuf
+		// support) is retried below as JSON
+		list, err = withListTimeout(ctx, func(ctx context.Context) (*unstructured.UnstructuredList, error) {
+			return c.listProtobuf(ctx, gvk, resource.Name, listNamespace, listOpts)
+		})
+		if err != nil {
+			list = nil
+		}
+	}
+
+	if list == nil {
+		list, err = withListTimeout(ctx, func(ctx context.Context) (*unstructured.UnstructuredList, error) {
+			return resourceClient.List(ctx, listOpts)
+		})
+		if err != nil && opts.FieldSelector != "" && apierrors.IsBadRequest(err) {
+			// The selector uses a field this type doesn't support server-side
+			fmt.Fprintf(os.Stderr, "Warning: field selector %q not supported for %s, listing all: %v\n", opts.FieldSelector, resourceType, err)
+			listOpts.FieldSelector = ""
+			list, err = withListTimeout(ctx, func(ctx context.Context) (*unstructured.UnstructuredList, error) {
+				return resourceClient.List(ctx, listOpts)
+			})
+		}
+		if err != nil && resource.Namespaced && namespace != "" && apierrors.IsNotFound(err) {
+			// Discovery may say namespaced while the server has made the type
+			// cluster-scoped, which has no per-namespace endpoint
+			fmt.Fprintf(os.Stderr, "Warning: %s can't be listed in namespace %s, listing it as cluster-scoped\n", resourceType, namespace)
+			list, err = withListTimeout(ctx, func(ctx context.Context) (*unstructured.UnstructuredList, error) {
+				return c.dynamicClient.Resource(gvr).List(ctx, listOpts)
+			})
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources: %v", err)
+		}
 	}
 	
 	// Convert to our Resource type
 	var resources []Resource
 	for _, item := range list.Items {
-		// Extract spec and status safely
-		var spec map[string]interface{}
-		if specObj, ok := item.Object["spec"]; ok {
-			if specMap, ok := specObj.(map[string]interface{}); ok {
-				spec = specMap
-			}
-		}
-		
-		var status map[string]interface{}
-		if statusObj, ok := item.Object["status"]; ok {
-			if statusMap, ok := statusObj.(map[string]interface{}); ok {
-				status = statusMap
-			}
+		if opts.NamePattern != nil && !opts.NamePattern.MatchString(item.GetName()) {
+			continue
 		}
-		
-		// Create resource
-		resource := Resource{
-			ApiVersion: item.GetAPIVersion(),
-			Kind:       item.GetKind(),
-			Metadata: Metadata{
-				Name:              item.GetName(),
-				Namespace:         item.GetNamespace(),
-				UID:               string(item.GetUID()),
-				ResourceVersion:   item.GetResourceVersion(),
-				CreationTimestamp: item.GetCreationTimestamp().String(),
-			},
-			Spec:   spec,
-			Status: status,
+
+		converted := toResource(&item)
+		if !resource.Namespaced {
+			// Objects stored before the type became cluster-scoped can still carry
+			// a namespace; drop it so they're keyed like every other cluster-scoped object
+			converted.Metadata.Namespace = ""
+		} else if namespace != "" && converted.Metadata.Namespace != "" && converted.Metadata.Namespace != namespace {
+			// Listed cluster-wide above
+			continue
 		}
-		
-		resources = append(resources, resource)
+		resources = append(resources, converted)
 	}
 	
 	return resources, nil
@@ -233,6 +546,7 @@ func DefaultExcludedResourceTypes() []string {
 }
 
 // CalculateSpecHash calculates a hash for the resource spec
+// DEPRECATED: Only returns a prefix of the spec JSON, use snapshot.CalculateSpecHash() instead
 func CalculateSpecHash(spec interface{}) (string, error) {
 	// Convert spec to JSON
 	data, err := json.Marshal(spec)
@@ -248,6 +562,105 @@ func CalculateSpecHash(spec interface{}) (string, error) {
 	return string(data), nil
 }
 
+// ResourceFromObject converts an object, e.g. one rewritten from a listed Resource's
+// Object, back to a Resource
+func ResourceFromObject(obj map[string]interface{}) Resource {
+	return toResource(&unstructured.Unstructured{Object: obj})
+}
+
+// toResource converts a listed object to a Resource
+func toResource(item *unstructured.Unstructured) Resource {
+	// Extract spec and status safely
+	var spec map[string]interface{}
+	if specObj, ok := item.Object["spec"]; ok {
+		if specMap, ok := specObj.(map[string]interface{}); ok {
+			spec = specMap
+		}
+	}
+
+	var status map[string]interface{}
+	if statusObj, ok := item.Object["status"]; ok {
+		if statusMap, ok := statusObj.(map[string]interface{}); ok {
+			status = statusMap
+		}
+	}
+
+	resourceType, _, _ := unstructured.NestedString(item.Object, "type")
+	data, _, _ := unstructured.NestedMap(item.Object, "data")
+	binaryData, _, _ := unstructured.NestedMap(item.Object, "binaryData")
+
+	return Resource{
+		ApiVersion: item.GetAPIVersion(),
+		Kind:       item.GetKind(),
+		Type:       resourceType,
+		Metadata: Metadata{
+			Name:              item.GetName(),
+			Namespace:         item.GetNamespace(),
+			UID:               string(item.GetUID()),
+			ResourceVersion:   item.GetResourceVersion(),
+			Generation:        item.GetGeneration(),
+			CreationTimestamp: item.GetCreationTimestamp().Time,
+			Labels:            item.GetLabels(),
+			Annotations:       item.GetAnnotations(),
+			OwnerReferences:   item.GetOwnerReferences(),
+		},
+		Spec:       spec,
+		Data:       data,
+		BinaryData: binaryData,
+		Status:     status,
+		Object:     item.Object,
+	}
+}
+
+// listProtobuf lists a built-in resource type using the protobuf encoding and
+// converts the typed objects to unstructured ones, as the dynamic client returns
+func (c *Client) listProtobuf(ctx context.Context, gvk schema.GroupVersionKind, resource, namespace string, listOpts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	gv := gvk.GroupVersion()
+	config := rest.CopyConfig(c.protobufConfig)
+	config.GroupVersion = &gv
+	config.APIPath = "/apis"
+	if gv.Group == "" {
+		config.APIPath = "/api"
+	}
+	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	config.ContentType = runtime.ContentTypeProtobuf
+	config.AcceptContentTypes = runtime.ContentTypeProtobuf + "," + runtime.ContentTypeJSON
+
+	restClient, err := rest.RESTClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := restClient.Get().
+		NamespaceIfScoped(namespace, namespace != "").
+		Resource(resource).
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Do(ctx).
+		Get()
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := meta.ExtractList(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &unstructured.UnstructuredList{}
+	for _, item := range items {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			return nil, err
+		}
+
+		// Decoded typed objects don't keep their apiVersion and kind
+		u := unstructured.Unstructured{Object: content}
+		u.SetGroupVersionKind(gvk)
+		list.Items = append(list.Items, u)
+	}
+	return list, nil
+}
+
 // Helper function
 func containsString(slice []string, s string) bool {
 	for _, item := range slice {
@@ -0,0 +1,146 @@
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var nodesResource = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+
+// fakeClient returns a client backed by fake discovery and dynamic clients
+// serving the given API resources and objects
+func fakeClient(resources []*metav1.APIResourceList, listKinds map[schema.GroupVersionResource]string, objects ...runtime.Object) (*Client, *dynamicfake.FakeDynamicClient) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+	discoveryClient := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: resources}}
+	return &Client{dynamicClient: dynamicClient, discoveryClient: discoveryClient}, dynamicClient
+}
+
+func node(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Node",
+		"metadata":   map[string]interface{}{"name": name},
+	}}
+}
+
+func TestCountNodes(t *testing.T) {
+	listKinds := map[schema.GroupVersionResource]string{nodesResource: "NodeList"}
+
+	client, _ := fakeClient(nil, listKinds, node("a"), node("b"), node("c"))
+	if got, err := client.CountNodes(context.Background()); err != nil || got != 3 {
+		t.Errorf("CountNodes() = %d, %v; want 3", got, err)
+	}
+
+	// Namespace-scoped users can't list nodes; that's not an error
+	client, dynamicClient := fakeClient(nil, listKinds)
+	dynamicClient.PrependReactor("list", "nodes", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "nodes"}, "", nil)
+	})
+	if got, err := client.CountNodes(context.Background()); err != nil || got != 0 {
+		t.Errorf("CountNodes() when forbidden = %d, %v; want 0 (unknown)", got, err)
+	}
+}
+
+func TestNodeCount(t *testing.T) {
+	page := func(continueToken string, remaining *int64) *unstructured.UnstructuredList {
+		list := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{*node("a")}}
+		list.SetContinue(continueToken)
+		list.SetRemainingItemCount(remaining)
+		return list
+	}
+	four := int64(4)
+
+	tests := []struct {
+		name string
+		list *unstructured.UnstructuredList
+		want int
+	}{
+		{name: "single page", list: page("", nil), want: 1},
+		{name: "remaining item count", list: page("token", &four), want: 5},
+		{name: "more pages without a count", list: page("token", nil), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nodeCount(tt.list); got != tt.want {
+				t.Errorf("nodeCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+var widgetsResource = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+func widget(namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": name},
+	}}
+	obj.SetNamespace(namespace)
+	return obj
+}
+
+// widgetClient serves Widgets, which discovery reports as namespaced or not
+func widgetClient(namespaced bool, objects ...runtime.Object) (*Client, *dynamicfake.FakeDynamicClient) {
+	resources := []*metav1.APIResourceList{{
+		GroupVersion: "example.com/v1",
+		APIResources: []metav1.APIResource{{Name: "widgets", Kind: "Widget", Namespaced: namespaced, Verbs: []string{"list"}}},
+	}}
+	return fakeClient(resources, map[schema.GroupVersionResource]string{widgetsResource: "WidgetList"}, objects...)
+}
+
+// listedNames returns namespace/name of each listed resource
+func listedNames(resources []Resource) []string {
+	var names []string
+	for _, res := range resources {
+		names = append(names, res.Metadata.Namespace+"/"+res.Metadata.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestListResourcesScopeChanges(t *testing.T) {
+	objects := []runtime.Object{widget("payments", "a"), widget("billing", "b"), widget("", "c")}
+
+	t.Run("stale namespaces of a cluster-scoped type", func(t *testing.T) {
+		client, _ := widgetClient(false, objects...)
+		resources, err := client.ListResources(context.Background(), "example.com/v1/Widget", "", ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := listedNames(resources), []string{"/a", "/b", "/c"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("listed %q, want %q", got, want)
+		}
+	})
+
+	t.Run("namespaced in discovery but cluster-scoped on the server", func(t *testing.T) {
+		client, dynamicClient := widgetClient(true, objects...)
+		dynamicClient.PrependReactor("list", "widgets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			if action.GetNamespace() == "" {
+				return false, nil, nil
+			}
+			return true, nil, apierrors.NewNotFound(widgetsResource.GroupResource(), "")
+		})
+
+		resources, err := client.ListResources(context.Background(), "example.com/v1/Widget", "payments", ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Objects of other namespaces come back from the cluster-wide list and are dropped
+		if got, want := listedNames(resources), []string{"/c", "payments/a"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("listed %q, want %q", got, want)
+		}
+	})
+}
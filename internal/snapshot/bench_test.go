@@ -0,0 +1,99 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/winson-sou/k8s-rdiff/internal/filter"
+	internal_k8s "github.com/winson-sou/k8s-rdiff/internal/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// syntheticClient returns a client backed by fakes serving the resources of a
+// synthetic snapshot as listed objects
+func syntheticClient(tb testing.TB, snap *Snapshot) *internal_k8s.Client {
+	tb.Helper()
+	client, _ := syntheticFakes(tb, snap)
+	return client
+}
+
+// syntheticFakes is syntheticClient that also returns the fake dynamic client,
+// e.g. to make some lists fail
+func syntheticFakes(tb testing.TB, snap *Snapshot) (*internal_k8s.Client, *dynamicfake.FakeDynamicClient) {
+	tb.Helper()
+
+	var objects []runtime.Object
+	for _, res := range snap.Resources {
+		obj := map[string]interface{}{}
+		if err := k8syaml.NewYAMLOrJSONDecoder(strings.NewReader(res.Manifest), 4096).Decode(&obj); err != nil {
+			tb.Fatal(err)
+		}
+		objects = append(objects, &unstructured.Unstructured{Object: obj})
+	}
+
+	resource := func(name, kind string) metav1.APIResource {
+		return metav1.APIResource{Name: name, Kind: kind, Namespaced: true, Verbs: []string{"list"}}
+	}
+	resources := []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			resource("configmaps", "ConfigMap"), resource("services", "Service"), resource("secrets", "Secret"),
+		}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{resource("deployments", "Deployment")}},
+	}
+	listKinds := map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "nodes"}:                      "NodeList",
+		{Version: "v1", Resource: "configmaps"}:                 "ConfigMapList",
+		{Version: "v1", Resource: "services"}:                   "ServiceList",
+		{Version: "v1", Resource: "secrets"}:                    "SecretList",
+		{Group: "apps", Version: "v1", Resource: "deployments"}: "DeploymentList",
+	}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+	return internal_k8s.NewClientFromInterfaces(
+		dynamicClient,
+		&fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: resources}},
+	), dynamicClient
+}
+
+func BenchmarkCapture(b *testing.B) {
+	defer func(connect func(internal_k8s.ClientOptions) (*internal_k8s.Client, error)) {
+		newClient = connect
+	}(newClient)
+
+	opts := CaptureOptions{
+		AllNamespaces:    true,
+		ExcludeNoisy:     true,
+		QuietExclusions:  true,
+		StripAnnotations: filter.DefaultStrippedAnnotations(),
+	}
+
+	for _, size := range []int{1000, 10000} {
+		baseline, _ := SyntheticPair(size, 0.1)
+		client := syntheticClient(b, baseline)
+		newClient = func(internal_k8s.ClientOptions) (*internal_k8s.Client, error) {
+			return client, nil
+		}
+
+		b.Run(fmt.Sprintf("resources=%d", len(baseline.Resources)), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				snap, err := Capture(context.Background(), opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(snap.Resources) != len(baseline.Resources) {
+					b.Fatalf("captured %d resources, want %d", len(snap.Resources), len(baseline.Resources))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package snapshot_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+	internal_k8s "github.com/winson-sou/k8s-rdiff/internal/k8s"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// captureAndCompare captures a listed object before and after a change and
+// compares the two single-resource snapshots
+func captureAndCompare(t *testing.T, before, after map[string]interface{}) *diff.DiffResult {
+	t.Helper()
+	snap := func(obj map[string]interface{}, taken time.Time) *snapshot.Snapshot {
+		res := snapshot.CaptureResource(t, snapshot.CaptureOptions{}, internal_k8s.ResourceFromObject(obj))
+		return &snapshot.Snapshot{
+			SchemaVersion: snapshot.SchemaVersion,
+			Timestamp:     taken,
+			Resources:     map[string]snapshot.ResourceInfo{res.Key(): res},
+		}
+	}
+	now := time.Now()
+	return diff.Compare(snap(before, now.Add(-time.Minute)), snap(after, now))
+}
+
+func role(resourceVersion string, rules ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "Role",
+		"metadata": map[string]interface{}{
+			"name":            "deployer",
+			"namespace":       "payments",
+			"resourceVersion": resourceVersion,
+		},
+		"rules": rules,
+	}
+}
+
+func rule(group, resource string, verbs ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiGroups": []interface{}{group},
+		"resources": []interface{}{resource},
+		"verbs":     verbs,
+	}
+}
+
+func TestRBACChangesOfCapturedRoles(t *testing.T) {
+	result := captureAndCompare(t,
+		role("1", rule("apps", "deployments", "get", "patch"), rule("", "pods", "get")),
+		role("2", rule("apps", "deployments", "get"), rule("", "pods", "get"), rule("", "secrets", "get", "delete")),
+	)
+	if len(result.Modified) != 1 {
+		t.Fatalf("got %d modified resources, want the role", len(result.Modified))
+	}
+
+	var got []string
+	for _, change := range result.Modified[0].RBACChanges() {
+		got = append(got, change.String())
+	}
+	want := []string{"- patch on deployments.apps", "+ delete, get on secrets"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RBACChanges() = %q, want %q", got, want)
+	}
+}
+
+func TestImmutableFieldsOfCapturedResources(t *testing.T) {
+	object := func(apiVersion, kind string, fields map[string]interface{}) map[string]interface{} {
+		obj := map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": "example", "namespace": "payments"},
+		}
+		for field, value := range fields {
+			obj[field] = value
+		}
+		return obj
+	}
+
+	tests := []struct {
+		name          string
+		before, after map[string]interface{}
+		want          []string
+	}{
+		{
+			name:   "StorageClass provisioner",
+			before: object("storage.k8s.io/v1", "StorageClass", map[string]interface{}{"provisioner": "ebs.csi.aws.com", "reclaimPolicy": "Delete"}),
+			after:  object("storage.k8s.io/v1", "StorageClass", map[string]interface{}{"provisioner": "efs.csi.aws.com", "reclaimPolicy": "Delete"}),
+			want:   []string{"provisioner"},
+		},
+		{
+			name:   "Secret type",
+			before: object("v1", "Secret", map[string]interface{}{"type": "Opaque"}),
+			after:  object("v1", "Secret", map[string]interface{}{"type": "kubernetes.io/tls"}),
+			want:   []string{"type"},
+		},
+		{
+			name:   "RoleBinding roleRef",
+			before: object("rbac.authorization.k8s.io/v1", "RoleBinding", map[string]interface{}{"roleRef": map[string]interface{}{"kind": "Role", "name": "reader"}}),
+			after:  object("rbac.authorization.k8s.io/v1", "RoleBinding", map[string]interface{}{"roleRef": map[string]interface{}{"kind": "Role", "name": "admin"}}),
+			want:   []string{"roleRef"},
+		},
+		{
+			name:   "mutable field",
+			before: object("v1", "Secret", map[string]interface{}{"type": "Opaque", "data": map[string]interface{}{"key": "YQ=="}}),
+			after:  object("v1", "Secret", map[string]interface{}{"type": "Opaque", "data": map[string]interface{}{"key": "Yg=="}}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := captureAndCompare(t, tt.before, tt.after)
+			if len(result.Modified) != 1 {
+				t.Fatalf("got %d modified resources, want 1", len(result.Modified))
+			}
+			if got := result.Modified[0].ImmutableFields; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ImmutableFields = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareCancelledCapture(t *testing.T) {
+	synthetic, _ := snapshot.SyntheticPair(200, 0)
+	snapshot.UseSyntheticClient(t, synthetic)
+	opts := snapshot.CaptureOptions{AllNamespaces: true, QuietExclusions: true}
+
+	baseline, err := snapshot.Capture(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cancel once the first resource type is listed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var total int
+	opts.Progress = func(p snapshot.CaptureProgress) {
+		total = p.Total
+		if p.Done == 1 {
+			cancel()
+		}
+	}
+	current, err := snapshot.Capture(ctx, opts)
+	if !errors.Is(err, snapshot.ErrCaptureCancelled) || current == nil {
+		t.Fatalf("Capture() = %v, %v, want a partial snapshot and ErrCaptureCancelled", current, err)
+	}
+	if !current.Partial || len(current.UnlistedTypes) != total-1 {
+		t.Fatalf("partial = %v with unlisted types %v, want the %d types after the first", current.Partial, current.UnlistedTypes, total-1)
+	}
+
+	result := diff.Compare(baseline, current)
+	if len(result.Removed) > 0 {
+		t.Errorf("%d resources of unlisted types reported as removed, e.g. %s", len(result.Removed), result.Removed[0].Resource.Key())
+	}
+	if len(result.Added) > 0 || len(result.Modified) > 0 {
+		t.Errorf("got %d added and %d modified resources, want none", len(result.Added), len(result.Modified))
+	}
+}
@@ -0,0 +1,22 @@
+package snapshot
+
+import (
+	"testing"
+
+	internal_k8s "github.com/winson-sou/k8s-rdiff/internal/k8s"
+)
+
+// CaptureResource lets the external tests, which compare captures with the diff
+// package, add listed resources to a snapshot the way a capture does
+var CaptureResource = captureResource
+
+// UseSyntheticClient makes captures list the resources of snap from fakes until
+// the test ends
+func UseSyntheticClient(t *testing.T, snap *Snapshot) {
+	client := syntheticClient(t, snap)
+	connect := newClient
+	newClient = func(internal_k8s.ClientOptions) (*internal_k8s.Client, error) {
+		return client, nil
+	}
+	t.Cleanup(func() { newClient = connect })
+}
@@ -0,0 +1,114 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"strings"
+
+	internal_k8s "github.com/winson-sou/k8s-rdiff/internal/k8s"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultVolatileFields returns the fields the API server or controllers change
+// without anyone changing the object: bookkeeping metadata and status. The uid
+// and creation time only change when an object is recreated with the same name.
+func DefaultVolatileFields() []string {
+	return []string{
+		"metadata.resourceVersion",
+		"metadata.managedFields",
+		"metadata.generation",
+		"metadata.uid",
+		"metadata.creationTimestamp",
+		"status",
+	}
+}
+
+// metadataFields are compared separately from the hash (see diff.CompareOptions),
+// so ignored annotations don't mark a resource modified
+var metadataFields = []string{"metadata.labels", "metadata.annotations"}
+
+// storedObject returns the resource as the snapshot keeps it: the listed object
+// with spec lists normalized, stripped annotations removed and without
+// managedFields. The manifest is this object, and the hash covers it except for
+// the volatile fields, so the detail view shows what was compared.
+func (c *captureSession) storedObject(resource internal_k8s.Resource) map[string]interface{} {
+	obj := make(map[string]interface{}, len(resource.Object))
+	for field, value := range resource.Object {
+		obj[field] = value
+	}
+
+	metadata := map[string]interface{}{}
+	if listed, ok := resource.Object["metadata"].(map[string]interface{}); ok {
+		for field, value := range listed {
+			metadata[field] = value
+		}
+	}
+	delete(metadata, "managedFields")
+	delete(metadata, "labels")
+	delete(metadata, "annotations")
+	if len(resource.Metadata.Labels) > 0 {
+		metadata["labels"] = resource.Metadata.Labels
+	}
+	if len(resource.Metadata.Annotations) > 0 {
+		metadata["annotations"] = resource.Metadata.Annotations
+	}
+	obj["metadata"] = metadata
+
+	if resource.Spec != nil {
+		obj["spec"] = NormalizeSpec(resource.Spec, c.opts.ListNormalizations)
+	}
+	return obj
+}
+
+// hashResource hashes a stored object except its volatile fields, so kinds
+// without a spec, such as ConfigMaps, Roles and ServiceAccounts, are compared too
+func (c *captureSession) hashResource(obj map[string]interface{}) (string, error) {
+	for _, path := range append(metadataFields, c.opts.volatileFields()...) {
+		obj = withoutField(obj, strings.Split(path, "."))
+	}
+	return CalculateSpecHash(obj)
+}
+
+// objectManifest renders a stored object as the YAML manifest kept in the snapshot
+func objectManifest(obj map[string]interface{}) (string, error) {
+	// Round-trip through JSON so values render as they do in the API (e.g. timestamps)
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return "", err
+	}
+	manifest, err := yaml.Marshal(generic)
+	if err != nil {
+		return "", err
+	}
+	return string(manifest), nil
+}
+
+// withoutField returns obj without the field at path, copying only the maps on
+// the way to it so the listed object is left untouched
+func withoutField(obj map[string]interface{}, path []string) map[string]interface{} {
+	value, ok := obj[path[0]]
+	if !ok {
+		return obj
+	}
+
+	var child map[string]interface{}
+	if len(path) > 1 {
+		if child, ok = value.(map[string]interface{}); !ok {
+			return obj
+		}
+	}
+
+	copied := make(map[string]interface{}, len(obj))
+	for field, value := range obj {
+		copied[field] = value
+	}
+	if len(path) == 1 {
+		delete(copied, path[0])
+	} else {
+		copied[path[0]] = withoutField(child, path[1:])
+	}
+	return copied
+}
@@ -0,0 +1,136 @@
+package snapshot
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	internal_k8s "github.com/winson-sou/k8s-rdiff/internal/k8s"
+)
+
+func roleBinding(role, resourceVersion string) internal_k8s.Resource {
+	return internal_k8s.ResourceFromObject(map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "RoleBinding",
+		"metadata": map[string]interface{}{
+			"name":            "readers",
+			"namespace":       "payments",
+			"resourceVersion": resourceVersion,
+			"annotations": map[string]interface{}{
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+			},
+			"managedFields": []interface{}{map[string]interface{}{"manager": "kubectl"}},
+		},
+		"roleRef": map[string]interface{}{
+			"apiGroup": "rbac.authorization.k8s.io",
+			"kind":     "Role",
+			"name":     role,
+		},
+		"subjects": []interface{}{
+			map[string]interface{}{"kind": "ServiceAccount", "name": "web", "namespace": "payments"},
+		},
+	})
+}
+
+// captureResource adds a listed resource to an empty snapshot as a capture would
+func captureResource(t *testing.T, opts CaptureOptions, resource internal_k8s.Resource) ResourceInfo {
+	t.Helper()
+	resourceFilter, err := opts.ResourceFilter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := &captureSession{opts: opts, resourceFilter: resourceFilter}
+	snap := &Snapshot{Resources: map[string]ResourceInfo{}}
+	session.addResource(snap, resource)
+	if len(snap.Resources) != 1 {
+		t.Fatalf("captured %d resources, want 1", len(snap.Resources))
+	}
+	for _, res := range snap.Resources {
+		return res
+	}
+	return ResourceInfo{}
+}
+
+func TestManifestMatchesHashedObject(t *testing.T) {
+	opts := CaptureOptions{StripAnnotations: []string{"kubectl.kubernetes.io/last-applied-configuration"}}
+	base := captureResource(t, opts, roleBinding("reader", "1"))
+	bumped := captureResource(t, opts, roleBinding("reader", "2"))
+	changed := captureResource(t, opts, roleBinding("admin", "3"))
+
+	for _, want := range []string{"roleRef:", "name: reader", "subjects:"} {
+		if !strings.Contains(base.Manifest, want) {
+			t.Errorf("manifest is missing %q:\n%s", want, base.Manifest)
+		}
+	}
+	for _, unwanted := range []string{"managedFields", "last-applied-configuration"} {
+		if strings.Contains(base.Manifest, unwanted) {
+			t.Errorf("manifest contains %q:\n%s", unwanted, base.Manifest)
+		}
+	}
+
+	if base.SpecHash != bumped.SpecHash {
+		t.Error("a resourceVersion bump changed the hash")
+	}
+	if base.SpecHash == changed.SpecHash {
+		t.Error("a roleRef change didn't change the hash")
+	}
+	if base.Manifest == changed.Manifest {
+		t.Error("a roleRef change that changed the hash didn't change the manifest")
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	secret := func() internal_k8s.Resource {
+		return internal_k8s.ResourceFromObject(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": "db", "namespace": "payments"},
+			"type":       "Opaque",
+			"data":       map[string]interface{}{"password": "aHVudGVyMg=="},
+		})
+	}
+
+	key := bytes.Repeat([]byte{1}, SecretDigestKeySize)
+	redacted := captureResource(t, CaptureOptions{SecretDigestKey: key}, secret())
+	again := captureResource(t, CaptureOptions{SecretDigestKey: key}, secret())
+	otherKey := captureResource(t, CaptureOptions{SecretDigestKey: bytes.Repeat([]byte{2}, SecretDigestKeySize)}, secret())
+	listed := captureResource(t, CaptureOptions{KeepSecretValues: true}, secret())
+
+	if strings.Contains(redacted.Manifest, "aHVudGVyMg==") || !strings.Contains(redacted.Manifest, "password: <redacted hmac-sha256:") {
+		t.Errorf("manifest isn't redacted by default:\n%s", redacted.Manifest)
+	}
+	if redacted.Manifest != again.Manifest {
+		t.Error("the same key gave different digests")
+	}
+	if redacted.Manifest == otherKey.Manifest {
+		t.Error("a different key gave the same digest")
+	}
+	if !strings.Contains(listed.Manifest, "password: aHVudGVyMg==") {
+		t.Errorf("manifest doesn't keep the listed value with KeepSecretValues:\n%s", listed.Manifest)
+	}
+	if listed.SpecHash != redacted.SpecHash {
+		t.Error("redacting changed the hash")
+	}
+}
+
+func TestWriteFileIsPrivate(t *testing.T) {
+	// Earlier releases wrote snapshots readable by everyone; rewriting one fixes that
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Snapshot{}).WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("snapshot written with mode %o, want 600", mode)
+	}
+}
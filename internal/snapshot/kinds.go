@@ -0,0 +1,60 @@
+package snapshot
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadKindsFile reads the resource types to capture from a file, one
+// GroupVersionKind per line in the form used in snapshots (e.g. apps/v1/Deployment,
+// or v1/ConfigMap for the core group). Lines starting with '#' are comments.
+func LoadKindsFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	var kinds []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := checkResourceType(line); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, lineNumber, err)
+		}
+		if !seen[line] {
+			seen[line] = true
+			kinds = append(kinds, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	if len(kinds) == 0 {
+		return nil, fmt.Errorf("%s lists no resource types", path)
+	}
+	return kinds, nil
+}
+
+// checkResourceType checks that a resource type is a GroupVersionKind like
+// apps/v1/Deployment or v1/ConfigMap
+func checkResourceType(resourceType string) error {
+	parts := strings.Split(resourceType, "/")
+	valid := len(parts) == 2 || len(parts) == 3
+	for _, part := range parts {
+		if part == "" || strings.ContainsAny(part, " \t") {
+			valid = false
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid resource type %q: expected GROUP/VERSION/KIND, or VERSION/KIND for the core group", resourceType)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultNamespaceConcurrency is how many namespaces a capture of several
+// Namespaces lists at once when CaptureOptions.NamespaceConcurrency is 0
+const DefaultNamespaceConcurrency = 4
+
+// namespaceCapture is what listing the resource types of one namespace, or the
+// cluster-scoped types, produced
+type namespaceCapture struct {
+	namespace string    // Empty for the cluster-scoped types
+	snapshot  *Snapshot // Resources and timings only
+	failed    []string  // Resource types that failed to list
+	unlisted  []string  // Resource types not listed because the capture was cancelled
+	err       error     // Listing failure with Strict
+}
+
+// captureNamespaces lists the namespaced resource types in each of the options'
+// Namespaces, NamespaceConcurrency namespaces at a time, and the cluster-scoped
+// types once, then merges the results into snapshot. A type failing to list in
+// one namespace is recorded in NamespaceFailedTypes without holding up or
+// failing the other namespaces. Exceeding MaxResources stops all of them.
+func (c *captureSession) captureNamespaces(ctx context.Context, snapshot *Snapshot, resourceTypes []string) (*Snapshot, error) {
+	opts := c.opts
+	snapshot.Namespace = strings.Join(opts.Namespaces, ",")
+
+	// Listing a cluster-scoped type in every namespace would return it each time
+	var clusterTypes, namespacedTypes []string
+	for _, resourceType := range resourceTypes {
+		if namespaced, err := c.client.IsNamespaced(resourceType); err == nil && namespaced {
+			namespacedTypes = append(namespacedTypes, resourceType)
+		} else {
+			// Types that can't be resolved fail to list, which should be reported once
+			clusterTypes = append(clusterTypes, resourceType)
+		}
+	}
+
+	total := len(clusterTypes) + len(opts.Namespaces)*len(namespacedTypes)
+	// With Strict, the first failure stops the other namespaces too, and so does
+	// exceeding MaxResources
+	listCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	listingStart := time.Now()
+	var listedMu sync.Mutex
+	done, captured := 0, 0
+	limit, limitExceeded := opts.MaxResources, false
+	listed := func(added int) {
+		listedMu.Lock()
+		defer listedMu.Unlock()
+		done++
+		if opts.Progress != nil {
+			opts.Progress(CaptureProgress{Done: done, Total: total, Elapsed: time.Since(listingStart)})
+		}
+
+		captured += added
+		if limit > 0 && captured > limit && !limitExceeded {
+			if opts.ConfirmLimit != nil && opts.ConfirmLimit(captured) {
+				limit = 0
+				return
+			}
+			limitExceeded = true
+			cancel()
+		}
+	}
+	if opts.Progress != nil {
+		opts.Progress(CaptureProgress{Total: total})
+	}
+
+	concurrency := opts.NamespaceConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultNamespaceConcurrency
+	}
+	slots := make(chan struct{}, concurrency)
+
+	captures := make([]namespaceCapture, len(opts.Namespaces)+1)
+	var wg sync.WaitGroup
+	for i := range captures {
+		namespace, types := "", clusterTypes
+		if i > 0 {
+			namespace, types = opts.Namespaces[i-1], namespacedTypes
+		}
+
+		wg.Add(1)
+		go func(i int, namespace string, types []string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			captures[i] = c.captureNamespace(listCtx, namespace, types, listed)
+			if captures[i].err != nil {
+				cancel()
+			}
+		}(i, namespace, types)
+	}
+	wg.Wait()
+
+	for _, capture := range captures {
+		if capture.err != nil {
+			return nil, capture.err
+		}
+	}
+
+	for _, capture := range captures {
+		for key, res := range capture.snapshot.Resources {
+			snapshot.Resources[key] = res
+		}
+		snapshot.Timings = append(snapshot.Timings, capture.snapshot.Timings...)
+
+		if capture.namespace == "" {
+			snapshot.FailedTypes = append(snapshot.FailedTypes, capture.failed...)
+			snapshot.UnlistedTypes = append(snapshot.UnlistedTypes, capture.unlisted...)
+			continue
+		}
+		if unknown := append(capture.failed, capture.unlisted...); len(unknown) > 0 {
+			if snapshot.NamespaceFailedTypes == nil {
+				snapshot.NamespaceFailedTypes = map[string][]string{}
+			}
+			snapshot.NamespaceFailedTypes[capture.namespace] = unknown
+			snapshot.Partial = true
+		}
+	}
+
+	if ctx.Err() != nil {
+		snapshot.Partial = true
+		return snapshot, fmt.Errorf("%w after %d of %d resource types across %d namespaces", ErrCaptureCancelled, done, total, len(opts.Namespaces))
+	}
+	if limitExceeded {
+		snapshot.Partial = true
+		return snapshot, fmt.Errorf("%w: captured %d objects after %d of %d resource types across %d namespaces (limit %d); narrow the capture with --namespace, --api-group or --ignore, or raise --max-resources",
+			ErrResourceLimitExceeded, len(snapshot.Resources), done, total, len(opts.Namespaces), opts.MaxResources)
+	}
+
+	if err := checkNamespaceFailures(snapshot, total); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// captureNamespace lists the given resource types in one namespace, or across
+// the cluster for an empty namespace, calling listed after each type with the
+// number of resources it added
+func (c *captureSession) captureNamespace(ctx context.Context, namespace string, resourceTypes []string, listed func(added int)) namespaceCapture {
+	capture := namespaceCapture{
+		namespace: namespace,
+		snapshot:  &Snapshot{Resources: map[string]ResourceInfo{}},
+	}
+	where := ""
+	if namespace != "" {
+		where = " in namespace " + namespace
+	}
+
+	for i, resourceType := range resourceTypes {
+		if ctx.Err() != nil {
+			capture.unlisted = resourceTypes[i:]
+			break
+		}
+
+		listStart := time.Now()
+		resources, err := c.client.ListResources(ctx, resourceType, namespace, c.listOpts)
+		if c.opts.RecordTimings {
+			capture.snapshot.Timings = append(capture.snapshot.Timings, TypeTiming{
+				ResourceType: resourceType,
+				Duration:     time.Since(listStart),
+				Count:        len(resources),
+			})
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				capture.unlisted = resourceTypes[i:]
+				break
+			}
+
+			if c.opts.Strict {
+				capture.err = fmt.Errorf("failed to list %s%s (not allowed with --strict): %v", resourceType, where, err)
+				break
+			}
+
+			fmt.Fprintf(os.Stderr, "Warning: failed to list %s%s: %v\n", resourceType, where, err)
+			capture.failed = append(capture.failed, resourceType)
+			listed(0)
+			continue
+		}
+
+		before := len(capture.snapshot.Resources)
+		for _, resource := range resources {
+			c.addResource(capture.snapshot, resource)
+		}
+		listed(len(capture.snapshot.Resources) - before)
+	}
+	return capture
+}
+
+// checkNamespaceFailures marks the snapshot partial if some resource types failed
+// to list, or fails if too many of the listings (one per type and namespace) did
+// for the snapshot to be useful
+func checkNamespaceFailures(snapshot *Snapshot, listingCount int) error {
+	failed := len(snapshot.FailedTypes)
+	for _, types := range snapshot.NamespaceFailedTypes {
+		failed += len(types)
+	}
+	if failed > 0 {
+		if float64(failed) > float64(listingCount)*maxFailedTypeRatio {
+			return fmt.Errorf("failed to list %d of %d resource types across the namespaces (check permissions and connectivity); refusing to use an incomplete snapshot", failed, listingCount)
+		}
+		snapshot.Partial = true
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	internal_k8s "github.com/winson-sou/k8s-rdiff/internal/k8s"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// captureSyntheticNamespaces captures namespaces of a synthetic snapshot whose
+// Secrets can't be listed in the team-03 namespace
+func captureSyntheticNamespaces(t *testing.T, synthetic *Snapshot, opts CaptureOptions) (*Snapshot, error) {
+	t.Helper()
+	client, dynamicClient := syntheticFakes(t, synthetic)
+	dynamicClient.PrependReactor("list", "secrets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() != "team-03" {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "", errors.New("not allowed"))
+	})
+
+	connect := newClient
+	newClient = func(internal_k8s.ClientOptions) (*internal_k8s.Client, error) {
+		return client, nil
+	}
+	defer func() { newClient = connect }()
+
+	opts.Namespaces = []string{"team-00", "team-03", "team-07"}
+	opts.QuietExclusions = true
+	return Capture(context.Background(), opts)
+}
+
+func TestCaptureNamespaces(t *testing.T) {
+	synthetic, _ := SyntheticPair(400, 0)
+	snap, err := captureSyntheticNamespaces(t, synthetic, CaptureOptions{NamespaceConcurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want, got []string
+	for key, res := range synthetic.Resources {
+		switch {
+		case res.Namespace == "team-03" && res.GroupVersionKind == "v1/Secret":
+		case res.Namespace == "team-00", res.Namespace == "team-03", res.Namespace == "team-07":
+			want = append(want, key)
+		}
+	}
+	for key := range snap.Resources {
+		got = append(got, key)
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	if len(got) == 0 || !reflect.DeepEqual(got, want) {
+		t.Errorf("captured %d resources, want the %d in the namespaces but team-03's Secrets", len(got), len(want))
+	}
+
+	if snap.Namespace != "team-00,team-03,team-07" {
+		t.Errorf("namespace = %q, want the captured namespaces", snap.Namespace)
+	}
+	if !snap.Partial || len(snap.FailedTypes) > 0 {
+		t.Errorf("partial = %v with failed types %v, want a partial snapshot with the failure in one namespace only", snap.Partial, snap.FailedTypes)
+	}
+	if want := map[string][]string{"team-03": {"v1/Secret"}}; !reflect.DeepEqual(snap.NamespaceFailedTypes, want) {
+		t.Errorf("namespace failed types = %v, want %v", snap.NamespaceFailedTypes, want)
+	}
+	if !snap.HasUnknownResources("v1/Secret", "team-03") || snap.HasUnknownResources("v1/Secret", "team-07") {
+		t.Error("Secrets should be unknown in team-03 only")
+	}
+}
+
+func TestCaptureNamespacesStrict(t *testing.T) {
+	synthetic, _ := SyntheticPair(400, 0)
+	snap, err := captureSyntheticNamespaces(t, synthetic, CaptureOptions{Strict: true})
+	if err == nil || !strings.Contains(err.Error(), "v1/Secret in namespace team-03") {
+		t.Errorf("Capture() = %v, want the failure of team-03's Secrets", err)
+	}
+	if snap != nil {
+		t.Error("a strict capture returned a snapshot despite a failure")
+	}
+}
+
+func TestCaptureNamespacesKeepsAskedSystemNamespaces(t *testing.T) {
+	synthetic, _ := SyntheticPair(400, 0)
+	snap, err := captureSyntheticNamespaces(t, synthetic, CaptureOptions{SystemNamespaces: []string{"team-00", "team-07", "team-08"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	captured := map[string]bool{}
+	for _, res := range snap.Resources {
+		captured[res.Namespace] = true
+	}
+	if !captured["team-00"] || !captured["team-07"] {
+		t.Errorf("captured namespaces %v, want the system namespaces that were asked for", captured)
+	}
+}
+
+func TestCaptureNamespacesResourceLimit(t *testing.T) {
+	synthetic, _ := SyntheticPair(400, 0)
+	snap, err := captureSyntheticNamespaces(t, synthetic, CaptureOptions{MaxResources: 10, NamespaceConcurrency: 1})
+	if !errors.Is(err, ErrResourceLimitExceeded) || snap == nil {
+		t.Fatalf("Capture() = %v, %v, want a partial snapshot and ErrResourceLimitExceeded", snap, err)
+	}
+	if !snap.Partial || len(snap.Resources) >= 40 {
+		t.Errorf("partial = %v with %d resources, want the capture stopped before listing all 40 it could", snap.Partial, len(snap.Resources))
+	}
+	if len(snap.NamespaceFailedTypes) == 0 {
+		t.Error("the namespaces left unlisted weren't recorded")
+	}
+}
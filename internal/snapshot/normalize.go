@@ -0,0 +1,111 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ListNormalization describes an order-insensitive list field. Wherever a list
+// named Field appears in a spec, its entries are sorted by the values of Keys
+// (in order) before the spec is hashed, so a controller reordering them doesn't
+// show up as a modification.
+type ListNormalization struct {
+	Field string
+	Keys  []string
+}
+
+// DefaultListNormalizations returns the list fields commonly reordered without
+// any semantic change: container env vars and ports
+func DefaultListNormalizations() []ListNormalization {
+	return []ListNormalization{
+		{Field: "env", Keys: []string{"name"}},
+		{Field: "ports", Keys: []string{"name", "port", "containerPort", "protocol"}},
+	}
+}
+
+// ParseListNormalization parses a rule given as FIELD=KEY[,KEY...], e.g. "volumeMounts=mountPath"
+func ParseListNormalization(rule string) (ListNormalization, error) {
+	parts := strings.SplitN(rule, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return ListNormalization{}, fmt.Errorf("invalid list normalization %q: expected FIELD=KEY[,KEY...]", rule)
+	}
+	return ListNormalization{Field: parts[0], Keys: strings.Split(parts[1], ",")}, nil
+}
+
+// NormalizeSpec returns a copy of spec with the lists matching rules sorted.
+// Lists whose entries aren't all objects are left in their original order.
+func NormalizeSpec(spec map[string]interface{}, rules []ListNormalization) map[string]interface{} {
+	if len(rules) == 0 {
+		return spec
+	}
+
+	byField := map[string][]string{}
+	for _, rule := range rules {
+		byField[rule.Field] = rule.Keys
+	}
+
+	normalized, _ := normalizeValue(spec, "", byField).(map[string]interface{})
+	return normalized
+}
+
+// normalizeValue copies value, sorting it if it's a list stored under one of the
+// fields in byField
+func normalizeValue(value interface{}, field string, byField map[string][]string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			copied[k] = normalizeValue(child, k, byField)
+		}
+		return copied
+
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = normalizeValue(child, "", byField)
+		}
+
+		if keys, ok := byField[field]; ok {
+			sortListByKeys(copied, keys)
+		}
+		return copied
+
+	default:
+		return v
+	}
+}
+
+// sortListByKeys stably sorts a list of objects by the given keys
+func sortListByKeys(list []interface{}, keys []string) {
+	sortKeys := make([]string, len(list))
+	for i, entry := range list {
+		obj, ok := entry.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		values := make([]string, len(keys))
+		for j, key := range keys {
+			if value, ok := obj[key]; ok {
+				values[j] = fmt.Sprint(value)
+			}
+		}
+		// NUL can't appear in a value, so it keeps the keys apart
+		sortKeys[i] = strings.Join(values, "\x00")
+	}
+
+	indexes := make([]int, len(list))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return sortKeys[indexes[a]] < sortKeys[indexes[b]]
+	})
+
+	sorted := make([]interface{}, len(list))
+	for i, index := range indexes {
+		sorted[i] = list[index]
+	}
+	copy(list, sorted)
+}
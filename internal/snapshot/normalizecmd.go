@@ -0,0 +1,82 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	internal_k8s "github.com/winson-sou/k8s-rdiff/internal/k8s"
+	k8sjson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// DefaultNormalizeTimeout bounds how long CaptureOptions.NormalizeCmd may take per resource
+const DefaultNormalizeTimeout = 5 * time.Second
+
+// normalizeResource pipes the resource as JSON through the options' NormalizeCmd
+// and returns the resource it writes back. The command may rewrite anything but
+// the resource's identity (apiVersion, kind, namespace and name).
+func (c *captureSession) normalizeResource(resource internal_k8s.Resource) (internal_k8s.Resource, error) {
+	timeout := c.opts.NormalizeTimeout
+	if timeout <= 0 {
+		timeout = DefaultNormalizeTimeout
+	}
+
+	obj, err := runNormalizeCmd(c.opts.NormalizeCmd, timeout, resource.Object)
+	if err != nil {
+		return resource, err
+	}
+
+	normalized := internal_k8s.ResourceFromObject(obj)
+	if normalized.ApiVersion != resource.ApiVersion || normalized.Kind != resource.Kind ||
+		normalized.Metadata.Namespace != resource.Metadata.Namespace || normalized.Metadata.Name != resource.Metadata.Name {
+		return resource, fmt.Errorf("the command changed the resource's apiVersion, kind, namespace or name")
+	}
+	return normalized, nil
+}
+
+// runNormalizeCmd runs command through the shell with obj as JSON on stdin, and
+// decodes the object it prints on stdout
+func runNormalizeCmd(command string, timeout time.Duration, obj map[string]interface{}) (map[string]interface{}, error) {
+	input, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second // Don't hang on children still holding the pipes after a timeout
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timed out after %s", timeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%v: %s", err, message)
+		}
+		return nil, err
+	}
+
+	// Decode numbers as int64 where possible, as the API machinery does
+	var normalized map[string]interface{}
+	if err := k8sjson.Unmarshal(stdout.Bytes(), &normalized); err != nil {
+		return nil, fmt.Errorf("invalid JSON output: %v", err)
+	}
+	if normalized == nil {
+		return nil, fmt.Errorf("no object in the output")
+	}
+	return normalized, nil
+}
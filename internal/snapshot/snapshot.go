@@ -1,13 +1,22 @@
 package snapshot
 
 import (
+	"context"
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/winson-sou/k8s-rdiff/internal/filter"
@@ -17,119 +26,850 @@ import (
 
 // ResourceInfo represents the metadata for a Kubernetes resource
 type ResourceInfo struct {
-	GroupVersionKind  string `json:"groupVersionKind"`
-	Namespace         string `json:"namespace"`
-	Name              string `json:"name"`
-	UID               string `json:"uid"`
-	ResourceVersion   string `json:"resourceVersion"`
-	CreationTimestamp string `json:"creationTimestamp"`
-	SpecHash          string `json:"specHash"`
-	Manifest          string `json:"manifest,omitempty"` // YAML representation of the resource
+	GroupVersionKind  string            `json:"groupVersionKind"`
+	Namespace         string            `json:"namespace"`
+	Name              string            `json:"name"`
+	UID               string            `json:"uid"`
+	ResourceVersion   string            `json:"resourceVersion"`
+	Generation        int64             `json:"generation,omitempty"` // metadata.generation; 0 if unset or captured by an older release
+	CreationTimestamp time.Time         `json:"creationTimestamp"`
+	SpecHash          string            `json:"specHash"`
+	Manifest          string            `json:"manifest,omitempty"` // YAML representation of the resource
+	Labels            map[string]string `json:"labels,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
 }
 
-// Snapshot represents a collection of resources at a point in time
+// SchemaVersion is the version of the saved snapshot format written by SaveToFile.
+// Bump it whenever a change to Snapshot or ResourceInfo would be misread by older
+// releases (adding an omitempty field is not such a change).
+const SchemaVersion = 2
+
+// Snapshot represents a collection of resources at a point in time.
+//
+// The JSON struct tags on Snapshot and ResourceInfo are the canonical schema of
+// saved snapshot files, versioned by SchemaVersion.
 type Snapshot struct {
-	Timestamp time.Time               `json:"timestamp"`
-	Namespace string                  `json:"namespace"`
-	Resources map[string]ResourceInfo `json:"resources"` // Key: GVK|NS|Name
+	SchemaVersion int                         `json:"schemaVersion"`
+	Timestamp     time.Time                   `json:"timestamp"`
+	Namespace     string                      `json:"namespace"`
+	Resources     map[string]ResourceInfo     `json:"resources"`               // Key: GVK|NS|Name
+	Partial       bool                        `json:"partial,omitempty"`       // Not every resource type was captured (cancelled or failed)
+	FailedTypes   []string                    `json:"failedTypes,omitempty"`   // Resource types that failed to list
+	UnlistedTypes []string                    `json:"unlistedTypes,omitempty"` // Resource types not listed because the capture stopped early
+	Timings       []TypeTiming                `json:"-"`                       // Per-type list durations, if recorded
+	ExcludedTypes []internal_k8s.ExcludedType `json:"-"`                       // Discovered resource types that weren't captured, and why
+	FilterHash    string                      `json:"filterHash,omitempty"`    // Identifies the filters used for the capture
+	ServerVersion string                      `json:"serverVersion,omitempty"` // Kubernetes version of the API server, if known
+	NodeCount     int                         `json:"nodeCount,omitempty"`     // Number of nodes in the cluster, if known
+	Note          string                      `json:"note,omitempty"`          // Free-text note given when saving, e.g. the action taken
+
+	// NamespaceFailedTypes lists, by namespace, the resource types that failed to
+	// list (or weren't listed) in just that namespace of a capture of several
+	// Namespaces. Other namespaces of those types are complete.
+	NamespaceFailedTypes map[string][]string `json:"namespaceFailedTypes,omitempty"`
 }
 
-// CaptureSnapshot captures all resources in the specified namespace
-func CaptureSnapshot(namespace, ignoreKindRegex, kubeconfigPath string) (*Snapshot, error) {
-	// Create Kubernetes client
-	client, err := internal_k8s.NewClient(kubeconfigPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+// TypeTiming records how long listing one resource type took
+type TypeTiming struct {
+	ResourceType string
+	Duration     time.Duration
+	Count        int // Number of resources listed
+}
+
+// SlowestTypes returns up to n recorded timings, slowest first
+func (s *Snapshot) SlowestTypes(n int) []TypeTiming {
+	timings := append([]TypeTiming(nil), s.Timings...)
+	sort.Slice(timings, func(i, j int) bool {
+		return timings[i].Duration > timings[j].Duration
+	})
+
+	if n > 0 && len(timings) > n {
+		timings = timings[:n]
+	}
+	return timings
+}
+
+// ClusterSummary describes the cluster the snapshot was captured from, e.g.
+// "Kubernetes v1.28.2, 3 nodes". It is empty for snapshots without version info.
+func (s *Snapshot) ClusterSummary() string {
+	if s.ServerVersion == "" {
+		return ""
 	}
 
-	// Create and compile the resource filter
-	resourceFilter := filter.NewResourceFilter().WithNoisy()
+	summary := "Kubernetes " + s.ServerVersion
+	if s.NodeCount == 1 {
+		summary += ", 1 node"
+	} else if s.NodeCount > 1 {
+		summary += fmt.Sprintf(", %d nodes", s.NodeCount)
+	}
+	return summary
+}
+
+// DescribeClusters summarizes the clusters two snapshots were captured from,
+// showing both when they differ (e.g. across an upgrade). It is empty when
+// neither snapshot has version info.
+func DescribeClusters(baseline, current *Snapshot) string {
+	before, after := baseline.ClusterSummary(), current.ClusterSummary()
+	switch {
+	case before == after:
+		return before
+	case before == "":
+		return "unknown → " + after
+	case after == "":
+		return before + " → unknown"
+	default:
+		return before + " → " + after
+	}
+}
+
+// ResourceCount is the number of captured resources in a group (a kind or a namespace)
+type ResourceCount struct {
+	Name  string
+	Count int
+}
+
+// CountByKind counts the captured resources per resource type (GVK), largest first
+func (s *Snapshot) CountByKind() []ResourceCount {
+	return s.countBy(func(res ResourceInfo) string { return res.GroupVersionKind })
+}
+
+// CountByNamespace counts the captured resources per namespace, largest first.
+// Cluster-scoped resources are counted under an empty name.
+func (s *Snapshot) CountByNamespace() []ResourceCount {
+	return s.countBy(func(res ResourceInfo) string { return res.Namespace })
+}
+
+func (s *Snapshot) countBy(key func(ResourceInfo) string) []ResourceCount {
+	totals := map[string]int{}
+	for _, res := range s.Resources {
+		totals[key(res)]++
+	}
+
+	counts := make([]ResourceCount, 0, len(totals))
+	for name, count := range totals {
+		counts = append(counts, ResourceCount{Name: name, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+// HasFailedType reports whether the given resource type (GVK) failed to list
+func (s *Snapshot) HasFailedType(gvk string) bool {
+	for _, failed := range s.FailedTypes {
+		if failed == gvk {
+			return true
+		}
+	}
+	return false
+}
+
+// HasUnknownType reports whether the resources of the given resource type (GVK)
+// are unknown, because it failed to list or the capture stopped before listing it
+func (s *Snapshot) HasUnknownType(gvk string) bool {
+	if s.HasFailedType(gvk) {
+		return true
+	}
+	for _, unlisted := range s.UnlistedTypes {
+		if unlisted == gvk {
+			return true
+		}
+	}
+	return false
+}
+
+// HasUnknownResources reports whether the resources of the given resource type
+// (GVK) in a namespace are unknown, because the type is unknown altogether (see
+// HasUnknownType) or failed to list in that namespace
+func (s *Snapshot) HasUnknownResources(gvk, namespace string) bool {
+	if s.HasUnknownType(gvk) {
+		return true
+	}
+	for _, failed := range s.NamespaceFailedTypes[namespace] {
+		if failed == gvk {
+			return true
+		}
+	}
+	return false
+}
+
+// maxFailedTypeRatio is the share of resource types that may fail to list before a
+// capture is treated as failed rather than partial. Beyond it the snapshot is mostly
+// empty and diffing it would report nearly everything as removed.
+const maxFailedTypeRatio = 0.5
+
+// Secret type and annotation of ServiceAccount tokens
+const (
+	serviceAccountTokenType      = "kubernetes.io/service-account-token"
+	serviceAccountNameAnnotation = "kubernetes.io/service-account.name"
+)
+
+// managedByLabel names the tool that manages a resource (e.g. Helm)
+const managedByLabel = "app.kubernetes.io/managed-by"
+
+// ErrNoNamespace is returned when the options name neither a namespace nor AllNamespaces,
+// so a cluster-wide capture is never made by accident
+var ErrNoNamespace = errors.New("no namespace given and AllNamespaces not set")
+
+// ErrCaptureCancelled is returned (wrapped) along with a partial snapshot when the
+// capture context is cancelled
+var ErrCaptureCancelled = errors.New("capture cancelled")
+
+// ErrResourceLimitExceeded is returned (wrapped) along with a partial snapshot when
+// the capture lists more objects than CaptureOptions.MaxResources allows
+var ErrResourceLimitExceeded = errors.New("resource limit exceeded")
+
+// CaptureOptions controls what a snapshot captures
+type CaptureOptions struct {
+	Namespace               string   // Namespace to capture (empty with AllNamespaces)
+	AllNamespaces           bool     // Capture all namespaces; Namespace must be empty
+	Namespaces              []string // Namespaces to capture concurrently, instead of Namespace or AllNamespaces
+	NamespaceConcurrency    int      // Namespaces listed at once with Namespaces (0 for DefaultNamespaceConcurrency)
+	KubeconfigPath          string   // Kubeconfig file (empty to merge the KUBECONFIG files)
+	Context                 string   // Kubeconfig context to use (empty for the current context)
+	As                      string   // User to impersonate (empty to use the kubeconfig identity)
+	AsGroups                []string // Groups to impersonate
+	AsUID                   string   // UID to impersonate
+	QPS                     float32  // Client-side request rate limit (0 for internal_k8s.DefaultQPS)
+	Burst                   int      // Client-side burst limit (0 for internal_k8s.DefaultBurst)
+	Protobuf                bool     // List built-in types as protobuf (CRDs are always listed as JSON)
+	CachedReads             bool     // List from the API server's watch cache (resourceVersion "0"); faster but may be stale
+	ExcludeNoisy            bool     // Exclude filter.DefaultNoisyResources()
+	IgnorePattern           string   // Regex of additional resource types to exclude
+	IgnoreGlobs             []string // Globs of additional resource types to exclude (e.g. */Event)
+	IncludePattern          string   // Regex of resource types to keep even if they match an exclusion
+	IncludeGroups           []string // API groups whose kinds are all kept even if they match an exclusion
+	NamePattern             string   // Regex of resource names to keep (applied after listing)
+	FieldSelector           string   // Server-side field selector (e.g. metadata.name=foo)
+	APIGroups               []string // API groups to discover (empty for all groups)
+	Kind                    string   // Capture only this kind (e.g. Deployment or deployments.apps), skipping discovery
+	Kinds                   []string // Capture exactly these resource types (e.g. apps/v1/Deployment), skipping discovery and the type filters
+	MaxResources            int      // Abort once more objects than this are captured (0 for no limit)
+	IncludeSystemNamespaces bool     // Keep resources in system namespaces
+	IncludeSATokens         bool     // Keep auto-generated ServiceAccount token Secrets
+	IgnoreManagedBy         []string // Drop resources whose app.kubernetes.io/managed-by label is one of these (case-insensitive)
+	SystemNamespaces        []string // Namespaces treated as system ones (empty for filter.CommonSystemNamespaces())
+	RecordTimings           bool     // Record how long each resource type took to list
+	Strict                  bool     // Fail instead of producing a partial snapshot when discovery or listing fails
+	QuietExclusions         bool     // Don't log the resource types excluded by the filters; they're still in Snapshot.ExcludedTypes
+	StripAnnotations        []string // Annotations removed from captured resources and manifests
+	KeepSecretValues        bool     // Store Secret values in manifests as listed instead of a keyed digest; hashes cover the values either way
+	SecretDigestKey         []byte   // Key the digests of Secret values are computed with (a random key for this process when empty)
+
+	// VolatileFields are dotted field paths left out of resource hashes, so changes
+	// to them alone don't mark a resource modified (nil for DefaultVolatileFields)
+	VolatileFields []string
+
+	// NormalizeCmd, when set, is a shell command every captured resource is piped
+	// through as JSON before it's hashed and stored; it prints the rewritten
+	// resource. Resources it fails on are kept as listed.
+	NormalizeCmd string
+
+	// NormalizeTimeout bounds NormalizeCmd per resource (DefaultNormalizeTimeout when 0)
+	NormalizeTimeout time.Duration
+
+	// ListNormalizations sort order-insensitive list fields in specs before hashing
+	// (none by default; see DefaultListNormalizations)
+	ListNormalizations []ListNormalization
+
+	// ConfirmLimit is asked whether to keep going once MaxResources is exceeded.
+	// Returning true lifts the limit for the rest of the capture; when nil the
+	// capture aborts.
+	ConfirmLimit func(total int) bool
+
+	// Progress, when set, is called once the resource types to capture are known
+	// and again after each one is listed
+	Progress func(CaptureProgress)
+}
+
+// CaptureProgress is how far a capture has got through the resource types
+type CaptureProgress struct {
+	Done    int           // Resource types listed, including failed ones
+	Total   int           // Resource types to list
+	Elapsed time.Duration // Time spent listing so far
+}
+
+// Percent returns the share of resource types listed, from 0 to 100
+func (p CaptureProgress) Percent() int {
+	if p.Total == 0 {
+		return 100
+	}
+	return p.Done * 100 / p.Total
+}
+
+// Remaining estimates the time left from the average time per resource type
+// listed so far. It is 0 until the first one is done.
+func (p CaptureProgress) Remaining() time.Duration {
+	if p.Done == 0 || p.Done >= p.Total {
+		return 0
+	}
+	return p.Elapsed / time.Duration(p.Done) * time.Duration(p.Total-p.Done)
+}
+
+// clientOptions returns the Kubernetes client settings of the options
+func (o CaptureOptions) clientOptions() internal_k8s.ClientOptions {
+	return internal_k8s.ClientOptions{
+		KubeconfigPath: o.KubeconfigPath,
+		Context:        o.Context,
+		As:             o.As,
+		AsGroups:       o.AsGroups,
+		AsUID:          o.AsUID,
+		QPS:            o.QPS,
+		Burst:          o.Burst,
+		Protobuf:       o.Protobuf,
+	}
+}
+
+// ResourceFilter builds the compiled resource type filter for the options
+func (o CaptureOptions) ResourceFilter() (*filter.ResourceFilter, error) {
+	resourceFilter := filter.NewResourceFilter()
+	if o.ExcludeNoisy {
+		resourceFilter.WithNoisy()
+	}
 
 	// Add custom exclusion patterns if provided
-	if ignoreKindRegex != "" {
-		resourceFilter.WithExcludes([]string{ignoreKindRegex})
+	if o.IgnorePattern != "" {
+		resourceFilter.WithExcludes([]string{o.IgnorePattern})
+	}
+
+	if len(o.IgnoreGlobs) > 0 {
+		resourceFilter.WithExcludeGlobs(o.IgnoreGlobs)
+	}
+
+	if o.IncludePattern != "" {
+		resourceFilter.WithIncludes([]string{o.IncludePattern})
+	}
+
+	if len(o.IncludeGroups) > 0 {
+		resourceFilter.WithIncludedGroups(o.IncludeGroups)
+	}
+
+	if len(o.APIGroups) > 0 {
+		// Groups that are always kept must also be discovered
+		resourceFilter.WithAPIGroups(o.APIGroups)
+		resourceFilter.WithAPIGroups(o.IncludeGroups)
+	}
+
+	if !o.IncludeSystemNamespaces {
+		// A system namespace that was asked for explicitly is still captured
+		asked := map[string]bool{o.Namespace: true}
+		for _, ns := range o.Namespaces {
+			asked[ns] = true
+		}
+		for _, ns := range o.systemNamespaces() {
+			if !asked[ns] {
+				resourceFilter.WithExcludedNamespaces([]string{ns})
+			}
+		}
 	}
 
 	if err := resourceFilter.Compile(); err != nil {
 		return nil, fmt.Errorf("failed to compile resource filter: %v", err)
 	}
 
-	snapshot := &Snapshot{
-		Timestamp: time.Now().UTC(),
-		Namespace: namespace,
-		Resources: make(map[string]ResourceInfo),
+	return resourceFilter, nil
+}
+
+// volatileFields returns the fields left out of resource hashes
+func (o CaptureOptions) volatileFields() []string {
+	if o.VolatileFields != nil {
+		return o.VolatileFields
 	}
+	return DefaultVolatileFields()
+}
 
-	// Discover API resources
-	resourceTypes, err := client.DiscoverResources(resourceFilter)
+// systemNamespaces returns the namespaces dropped unless IncludeSystemNamespaces is set
+func (o CaptureOptions) systemNamespaces() []string {
+	if len(o.SystemNamespaces) > 0 {
+		return o.SystemNamespaces
+	}
+	return filter.CommonSystemNamespaces()
+}
+
+// FilterHash identifies the filters that decide which resources get captured, so
+// snapshots taken with different filters can be detected before comparing them
+func (o CaptureOptions) FilterHash() (string, error) {
+	resourceFilter, err := o.ResourceFilter()
+	if err != nil {
+		return "", err
+	}
+
+	return CalculateSpecHash(map[string]interface{}{
+		"excludes":           resourceFilter.ExcludePatterns,
+		"includes":           resourceFilter.IncludePatterns,
+		"namePattern":        o.NamePattern,
+		"fieldSelector":      o.FieldSelector,
+		"apiGroups":          resourceFilter.APIGroups,
+		"kind":               o.Kind,
+		"kinds":              o.Kinds,
+		"excludedNamespaces": resourceFilter.ExcludeNamespaces,
+		"includeSATokens":    o.IncludeSATokens,
+		"ignoreManagedBy":    o.IgnoreManagedBy,
+	})
+}
+
+// CaptureSnapshot captures all resources in the specified namespace, or in every namespace
+// if allNamespaces is set, excluding noisy resources and, unless includeSystemNamespaces
+// is set, resources in system namespaces
+func CaptureSnapshot(namespace string, allNamespaces bool, ignoreKindRegex, kubeconfigPath string, includeSystemNamespaces bool) (*Snapshot, error) {
+	return Capture(context.Background(), CaptureOptions{
+		Namespace:               namespace,
+		AllNamespaces:           allNamespaces,
+		KubeconfigPath:          kubeconfigPath,
+		ExcludeNoisy:            true,
+		IgnorePattern:           ignoreKindRegex,
+		IncludeSystemNamespaces: includeSystemNamespaces,
+		StripAnnotations:        filter.DefaultStrippedAnnotations(),
+	})
+}
+
+// Capture captures all resources selected by the options. If ctx is cancelled
+// part-way, the resources collected so far are returned in a snapshot marked
+// Partial together with an error wrapping ErrCaptureCancelled. Exceeding
+// MaxResources does the same with ErrResourceLimitExceeded.
+func Capture(ctx context.Context, opts CaptureOptions) (*Snapshot, error) {
+	session, err := newCaptureSession(opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to discover API resources: %v", err)
+		return nil, err
+	}
+
+	snapshot := session.newSnapshot(ctx)
+
+	resourceTypes, excludedTypes, err := session.resourceTypes()
+	if err != nil {
+		return nil, err
+	}
+	snapshot.ExcludedTypes = excludedTypes
+
+	if len(opts.Namespaces) > 0 {
+		return session.captureNamespaces(ctx, snapshot, resourceTypes)
+	}
+
+	listingStart := time.Now()
+	reportProgress := func(done int) {
+		if opts.Progress != nil {
+			opts.Progress(CaptureProgress{Done: done, Total: len(resourceTypes), Elapsed: time.Since(listingStart)})
+		}
 	}
+	reportProgress(0)
 
 	// Capture resources for each resource type
-	for _, resourceType := range resourceTypes {
-		resources, err := client.ListResources(resourceType, namespace)
+	for i, resourceType := range resourceTypes {
+		if ctx.Err() != nil {
+			stopEarly(snapshot, resourceTypes[i:])
+			return snapshot, fmt.Errorf("%w after %d of %d resource types", ErrCaptureCancelled, i, len(resourceTypes))
+		}
+
+		listStart := time.Now()
+		resources, err := session.client.ListResources(ctx, resourceType, opts.Namespace, session.listOpts)
+		if opts.RecordTimings {
+			snapshot.Timings = append(snapshot.Timings, TypeTiming{
+				ResourceType: resourceType,
+				Duration:     time.Since(listStart),
+				Count:        len(resources),
+			})
+		}
 		if err != nil {
+			if ctx.Err() != nil {
+				stopEarly(snapshot, resourceTypes[i:])
+				return snapshot, fmt.Errorf("%w after %d of %d resource types", ErrCaptureCancelled, i, len(resourceTypes))
+			}
+
+			if opts.Strict {
+				return nil, fmt.Errorf("failed to list %s (not allowed with --strict): %v", resourceType, err)
+			}
+
 			// Just log the error and continue with other resources
 			fmt.Fprintf(os.Stderr, "Warning: failed to list %s: %v\n", resourceType, err)
+			snapshot.FailedTypes = append(snapshot.FailedTypes, resourceType)
+			reportProgress(i + 1)
 			continue
 		}
 
 		for _, resource := range resources {
-			// Generate a unique key for the resource
-			gvk := fmt.Sprintf("%s/%s", resource.ApiVersion, resource.Kind)
-
-			// Extract resource manifest for YAML diffing later
-			resourceData, err := json.Marshal(resource)
-
-			// Create resource info
-			resourceInfo := ResourceInfo{
-				GroupVersionKind:  gvk,
-				Namespace:         resource.Metadata.Namespace,
-				Name:              resource.Metadata.Name,
-				UID:               resource.Metadata.UID,
-				ResourceVersion:   resource.Metadata.ResourceVersion,
-				CreationTimestamp: resource.Metadata.CreationTimestamp,
-				SpecHash:          resource.Metadata.ResourceVersion, // Fall back to resource version if hash fails
+			session.addResource(snapshot, resource)
+		}
+		reportProgress(i + 1)
+
+		if limit := opts.MaxResources; limit > 0 && len(snapshot.Resources) > limit {
+			total := len(snapshot.Resources)
+			if opts.ConfirmLimit == nil || !opts.ConfirmLimit(total) {
+				stopEarly(snapshot, resourceTypes[i+1:])
+				return snapshot, fmt.Errorf("%w: captured %d objects after %d of %d resource types (limit %d); narrow the capture with --namespace, --api-group or --ignore, or raise --max-resources",
+					ErrResourceLimitExceeded, total, i+1, len(resourceTypes), limit)
 			}
+			opts.MaxResources = 0
+		}
+	}
 
-			// Calculate spec hash
-			if resource.Spec != nil {
-				if hash, err := internal_k8s.CalculateSpecHash(resource.Spec); err == nil {
-					resourceInfo.SpecHash = hash
-				}
+	if err := checkFailedTypes(snapshot, len(resourceTypes)); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// stopEarly marks the snapshot partial and records the resource types it stopped
+// before listing, so comparing it doesn't report their resources as removed
+func stopEarly(snapshot *Snapshot, unlisted []string) {
+	snapshot.Partial = true
+	snapshot.UnlistedTypes = append(snapshot.UnlistedTypes, unlisted...)
+}
+
+// checkFailedTypes marks the snapshot partial if some resource types failed to
+// list, or fails if too many did for the snapshot to be useful
+func checkFailedTypes(snapshot *Snapshot, typeCount int) error {
+	if failed := len(snapshot.FailedTypes); failed > 0 {
+		if float64(failed) > float64(typeCount)*maxFailedTypeRatio {
+			return fmt.Errorf("failed to list %d of %d resource types (check permissions and connectivity); refusing to use an incomplete snapshot", failed, typeCount)
+		}
+		snapshot.Partial = true
+	}
+	return nil
+}
+
+// newClient connects to the cluster; benchmarks replace it with fake clients
+var newClient = internal_k8s.NewClient
+
+// captureSession holds what capturing needs besides the resources themselves:
+// the compiled filters and a connected client
+type captureSession struct {
+	opts           CaptureOptions
+	resourceFilter *filter.ResourceFilter
+	listOpts       internal_k8s.ListOptions
+	client         *internal_k8s.Client
+	filterHash     string
+
+	normalizeFailed sync.Once // Reports the first resource NormalizeCmd failed on
+}
+
+// newCaptureSession compiles the options' filters and connects to the cluster
+func newCaptureSession(opts CaptureOptions) (*captureSession, error) {
+	if opts.Namespace == "" && !opts.AllNamespaces && len(opts.Namespaces) == 0 {
+		return nil, ErrNoNamespace
+	}
+	if opts.Namespace != "" && opts.AllNamespaces {
+		return nil, fmt.Errorf("namespace %q can't be combined with AllNamespaces", opts.Namespace)
+	}
+	if len(opts.Namespaces) > 0 && (opts.Namespace != "" || opts.AllNamespaces) {
+		return nil, errors.New("Namespaces can't be combined with Namespace or AllNamespaces")
+	}
+	for _, namespace := range opts.Namespaces {
+		if namespace == "" {
+			return nil, errors.New("empty namespace in Namespaces")
+		}
+	}
+
+	// Create and compile the resource filter
+	resourceFilter, err := opts.ResourceFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts := internal_k8s.ListOptions{FieldSelector: opts.FieldSelector, CachedReads: opts.CachedReads}
+	if opts.NamePattern != "" {
+		if listOpts.NamePattern, err = regexp.Compile(opts.NamePattern); err != nil {
+			return nil, fmt.Errorf("invalid name pattern %q: %v", opts.NamePattern, err)
+		}
+	}
+
+	// Create Kubernetes client
+	client, err := newClient(opts.clientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	filterHash, err := opts.FilterHash()
+	if err != nil {
+		return nil, err
+	}
+
+	return &captureSession{
+		opts:           opts,
+		resourceFilter: resourceFilter,
+		listOpts:       listOpts,
+		client:         client,
+		filterHash:     filterHash,
+	}, nil
+}
+
+// newSnapshot returns an empty snapshot stamped with the current time and cluster details
+func (c *captureSession) newSnapshot(ctx context.Context) *Snapshot {
+	snapshot := &Snapshot{
+		SchemaVersion: SchemaVersion,
+		Timestamp:     time.Now().UTC(),
+		Namespace:     c.opts.Namespace,
+		Resources:     make(map[string]ResourceInfo),
+		FilterHash:    c.filterHash,
+	}
+
+	// Cluster details are informational only, so failing to get them isn't an error
+	if version, err := c.client.ServerVersion(); err == nil {
+		snapshot.ServerVersion = version
+	}
+	if nodes, err := c.client.CountNodes(ctx); err == nil {
+		snapshot.NodeCount = nodes
+	}
+
+	return snapshot
+}
+
+// resourceTypes discovers the resource types to capture, or just resolves the one
+// kind that was asked for. It also returns the discovered types that were left out.
+func (c *captureSession) resourceTypes() ([]string, []internal_k8s.ExcludedType, error) {
+	if c.opts.Kind != "" {
+		resourceType, err := c.client.ResolveKind(c.opts.Kind)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []string{resourceType}, nil, nil
+	}
+
+	if len(c.opts.Kinds) > 0 {
+		// Every listed type must exist, or captures of clusters with different
+		// CRDs would silently differ
+		var missing []string
+		for _, resourceType := range c.opts.Kinds {
+			if err := c.client.CheckResourceType(resourceType); err != nil {
+				missing = append(missing, err.Error())
 			}
+		}
+		if len(missing) > 0 {
+			return nil, nil, fmt.Errorf("%d listed resource types can't be captured from this cluster: %s", len(missing), strings.Join(missing, "; "))
+		}
+		return c.opts.Kinds, nil, nil
+	}
 
-			// Add YAML manifest if available
-			if err == nil {
-				var obj interface{}
-				_ = json.Unmarshal(resourceData, &obj)
-				if yamlData, err := yaml.Marshal(obj); err == nil {
-					resourceInfo.Manifest = string(yamlData)
-				}
+	resourceTypes, excludedTypes, err := c.client.DiscoverResources(c.resourceFilter)
+	if err != nil {
+		if !internal_k8s.IsPartialDiscoveryError(err) {
+			return nil, nil, err
+		}
+		if c.opts.Strict {
+			return nil, nil, fmt.Errorf("partial discovery failure (not allowed with --strict): %v", err)
+		}
+		// Continue with partial results if some groups failed
+		fmt.Fprintf(os.Stderr, "Warning: partial discovery failure: %v\n", err)
+	}
+
+	if !c.opts.QuietExclusions {
+		for _, excluded := range excludedTypes {
+			if excluded.Filtered {
+				fmt.Fprintf(os.Stderr, "Ignoring resource type: %s (%s)\n", excluded.ResourceType, excluded.Reason)
 			}
+		}
+	}
+	return resourceTypes, excludedTypes, nil
+}
 
-			// Add to snapshot
-			key := fmt.Sprintf("%s|%s|%s", gvk, resource.Metadata.Namespace, resource.Metadata.Name)
-			snapshot.Resources[key] = resourceInfo
+// addResource adds a listed resource to the snapshot, unless it's in an excluded
+// namespace, managed by an ignored tool or an auto-generated ServiceAccount token
+// that wasn't asked for
+func (c *captureSession) addResource(snapshot *Snapshot, resource internal_k8s.Resource) {
+	if c.resourceFilter.ShouldExcludeNamespace(resource.Metadata.Namespace) {
+		return
+	}
+	if c.ignoresManager(resource.Metadata.Labels[managedByLabel]) {
+		return
+	}
+	if !c.opts.IncludeSATokens && isGeneratedSAToken(resource) {
+		return
+	}
+
+	if c.opts.NormalizeCmd != "" {
+		normalized, err := c.normalizeResource(resource)
+		if err != nil {
+			// Report the first failure only; a broken command would fail on every resource
+			c.normalizeFailed.Do(func() {
+				fmt.Fprintf(os.Stderr, "Warning: normalize command failed for %s/%s %s, keeping it (and any others it fails on) as listed: %v\n",
+					resource.ApiVersion, resource.Kind, resource.Metadata.Name, err)
+			})
 		}
+		resource = normalized
 	}
 
-	return snapshot, nil
+	for _, annotation := range c.opts.StripAnnotations {
+		delete(resource.Metadata.Annotations, annotation)
+	}
+	if len(resource.Metadata.Annotations) == 0 {
+		resource.Metadata.Annotations = nil
+	}
+
+	// Generate a unique key for the resource
+	gvk := fmt.Sprintf("%s/%s", resource.ApiVersion, resource.Kind)
+
+	// Create resource info
+	resourceInfo := ResourceInfo{
+		GroupVersionKind:  gvk,
+		Namespace:         resource.Metadata.Namespace,
+		Name:              resource.Metadata.Name,
+		UID:               resource.Metadata.UID,
+		ResourceVersion:   resource.Metadata.ResourceVersion,
+		Generation:        resource.Metadata.Generation,
+		CreationTimestamp: resource.Metadata.CreationTimestamp,
+		Labels:            resource.Metadata.Labels,
+		Annotations:       resource.Metadata.Annotations,
+		SpecHash:          resource.Metadata.ResourceVersion, // Fall back to resource version if hash fails
+	}
+
+	// Hash and keep the manifest of the same object, for YAML diffing later. Secret
+	// values are hashed as listed, so redacting them doesn't change the hash.
+	obj := c.storedObject(resource)
+	if hash, err := c.hashResource(obj); err == nil {
+		resourceInfo.SpecHash = hash
+	}
+	if !c.opts.KeepSecretValues && resource.ApiVersion == "v1" && resource.Kind == "Secret" && resource.Data != nil {
+		obj["data"] = redactSecretData(resource.Data, c.opts.SecretDigestKey)
+	}
+	if manifest, err := objectManifest(obj); err == nil {
+		resourceInfo.Manifest = manifest
+	}
+
+	// Add to snapshot
+	key := fmt.Sprintf("%s|%s|%s", gvk, resource.Metadata.Namespace, resource.Metadata.Name)
+	snapshot.Resources[key] = resourceInfo
 }
 
-// SaveToFile persists the snapshot to a temporary file
-func (s *Snapshot) SaveToFile() error {
-	// Create a temporary directory or use XDG_RUNTIME_DIR
-	tempDir := os.TempDir()
+// ignoresManager reports whether resources managed by the given tool are dropped
+func (c *captureSession) ignoresManager(manager string) bool {
+	if manager == "" {
+		return false
+	}
+	for _, ignored := range c.opts.IgnoreManagedBy {
+		if strings.EqualFold(manager, ignored) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSecretData returns the values of a Secret replaced with an HMAC-SHA256
+// digest under key, so manifests show which keys changed without holding the
+// secrets themselves. Without the key, the digests can't be checked against
+// guessed values.
+func redactSecretData(data map[string]interface{}, key []byte) map[string]interface{} {
+	if len(key) == 0 {
+		key = processDigestKey()
+	}
+	redacted := make(map[string]interface{}, len(data))
+	for field, value := range data {
+		// Base64 never holds a "<", so this is a digest already
+		if text, ok := value.(string); ok && strings.HasPrefix(text, "<redacted ") {
+			redacted[field] = text
+			continue
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(fmt.Sprint(value)))
+		redacted[field] = fmt.Sprintf("<redacted hmac-sha256:%s>", hex.EncodeToString(mac.Sum(nil))[:32])
+	}
+	return redacted
+}
+
+// RedactSecretManifest returns a manifest with the values of a Secret replaced by
+// digests under a key for this process, and any other manifest as is. Manifests
+// captured with KeepSecretValues hold the values; redacting them before they're
+// written elsewhere keeps the secrets in the snapshot.
+func RedactSecretManifest(manifest string) (string, error) {
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal([]byte(manifest), &obj); err != nil {
+		return "", err
+	}
+	data, ok := obj["data"].(map[interface{}]interface{})
+	if obj["apiVersion"] != "v1" || obj["kind"] != "Secret" || !ok {
+		return manifest, nil
+	}
+
+	values := make(map[string]interface{}, len(data))
+	for field, value := range data {
+		values[fmt.Sprint(field)] = value
+	}
+	obj["data"] = redactSecretData(values, nil)
+	redacted, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return string(redacted), nil
+}
+
+// SecretDigestKeySize is the length in bytes of the keys Secret values are digested with
+const SecretDigestKeySize = 32
+
+var (
+	digestKeyOnce sync.Once
+	digestKey     []byte
+)
+
+// processDigestKey returns a random key generated once per process, for captures
+// without a SecretDigestKey. Their digests only compare within the process.
+func processDigestKey() []byte {
+	digestKeyOnce.Do(func() {
+		digestKey = make([]byte, SecretDigestKeySize)
+		if _, err := rand.Read(digestKey); err != nil {
+			panic(fmt.Sprintf("failed to generate secret digest key: %v", err))
+		}
+	})
+	return digestKey
+}
+
+// isGeneratedSAToken reports whether a resource is a ServiceAccount token Secret
+// created by Kubernetes rather than by a user: owned by its ServiceAccount, or
+// named <serviceaccount>-token-<suffix> by the legacy token controller. These
+// come and go with their ServiceAccounts and are rotated, so they're mostly noise.
+func isGeneratedSAToken(resource internal_k8s.Resource) bool {
+	if resource.ApiVersion != "v1" || resource.Kind != "Secret" || resource.Type != serviceAccountTokenType {
+		return false
+	}
+
+	for _, owner := range resource.Metadata.OwnerReferences {
+		if owner.Kind == "ServiceAccount" {
+			return true
+		}
+	}
+
+	serviceAccount := resource.Metadata.Annotations[serviceAccountNameAnnotation]
+	return serviceAccount != "" && strings.HasPrefix(resource.Metadata.Name, serviceAccount+"-token-")
+}
+
+// SaveToFile persists the snapshot in dir (os.TempDir() when empty) and returns
+// the path written. File names carry the capture time to the nanosecond, and a
+// numeric suffix is added if a file with the same name already exists.
+func (s *Snapshot) SaveToFile(dir string) (string, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %v", err)
+	}
 
 	// Create a unique filename based on timestamp
-	timestamp := s.Timestamp.Format("20060102-150405")
+	timestamp := s.Timestamp.Format("20060102-150405.000000000")
 	namespace := s.Namespace
 	if namespace == "" {
 		namespace = "all-namespaces"
 	}
-	filename := filepath.Join(tempDir, fmt.Sprintf("k8s-rdiff-%s-%s.json", namespace, timestamp))
+	base := fmt.Sprintf("k8s-rdiff-%s-%s", namespace, timestamp)
+
+	filename := filepath.Join(dir, base+".json")
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			break
+		}
+		filename = filepath.Join(dir, fmt.Sprintf("%s-%d.json", base, n))
+	}
+
+	if err := s.WriteFile(filename); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// WriteFile writes the snapshot to the given path in the current schema
+func (s *Snapshot) WriteFile(filename string) error {
+	// Always write the current schema
+	s.SchemaVersion = SchemaVersion
 
 	// Marshal snapshot to JSON
 	data, err := json.MarshalIndent(s, "", "  ")
@@ -138,20 +878,119 @@ func (s *Snapshot) SaveToFile() error {
 	}
 
 	// Write snapshot to file
-	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+	// Snapshots hold whole manifests, so only the owner may read them. WriteFile
+	// keeps the mode of an existing file, which earlier releases wrote 0644.
+	if err := ioutil.WriteFile(filename, data, 0600); err != nil {
 		return fmt.Errorf("failed to write snapshot: %v", err)
 	}
+	if err := os.Chmod(filename, 0600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+
+	return nil
+}
+
+// migrations upgrade the raw JSON of a snapshot from the keyed schema version to
+// the next one. Versions without an entry only added fields, which load as zero values.
+var migrations = map[int]func(raw map[string]interface{}) error{
+	1: migrateCreationTimestamps,
+}
+
+// legacyTimestampLayout is how schema version 1 stored creationTimestamp (Go's
+// time.Time.String format)
+const legacyTimestampLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// migrateCreationTimestamps converts creationTimestamp from the version 1 string
+// form to RFC 3339 so it decodes as a time.Time
+func migrateCreationTimestamps(raw map[string]interface{}) error {
+	resources, _ := raw["resources"].(map[string]interface{})
+	for key, value := range resources {
+		resource, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		legacy, _ := resource["creationTimestamp"].(string)
+		if legacy == "" {
+			delete(resource, "creationTimestamp")
+			continue
+		}
 
+		created, err := time.Parse(legacyTimestampLayout, legacy)
+		if err != nil {
+			return fmt.Errorf("resource %s: invalid creationTimestamp %q: %v", key, legacy, err)
+		}
+		resource["creationTimestamp"] = created.Format(time.RFC3339Nano)
+	}
 	return nil
 }
 
-// LoadFromFile loads a snapshot from a file
+// LoadFromFile loads a snapshot from a file, migrating older schema versions
 func LoadFromFile(filename string) (*Snapshot, error) {
 	data, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read snapshot: %v", err)
 	}
 
+	snapshot, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s: %v", filename, err)
+	}
+
+	return snapshot, nil
+}
+
+// ReadSchemaVersion returns the schema version of a saved snapshot. Snapshots
+// written before versioning was introduced report version 0.
+func ReadSchemaVersion(data []byte) (int, error) {
+	var header struct {
+		SchemaVersion *int `json:"schemaVersion"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal snapshot: %v", err)
+	}
+
+	if header.SchemaVersion == nil {
+		return 0, nil
+	}
+	if *header.SchemaVersion < 1 {
+		return 0, fmt.Errorf("invalid schema version %d", *header.SchemaVersion)
+	}
+	return *header.SchemaVersion, nil
+}
+
+// Decode parses a saved snapshot, migrating older schema versions to the current one
+func Decode(data []byte) (*Snapshot, error) {
+	version, err := ReadSchemaVersion(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("schema version %d is newer than the version %d supported by this build of k8s-rdiff; please upgrade",
+			version, SchemaVersion)
+	}
+
+	if version < SchemaVersion {
+		var raw map[string]interface{}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal snapshot: %v", err)
+		}
+
+		for ; version < SchemaVersion; version++ {
+			if migrate, ok := migrations[version]; ok {
+				if err := migrate(raw); err != nil {
+					return nil, fmt.Errorf("failed to migrate from schema version %d: %v", version, err)
+				}
+			}
+		}
+		raw["schemaVersion"] = SchemaVersion
+
+		if data, err = json.Marshal(raw); err != nil {
+			return nil, fmt.Errorf("failed to migrate snapshot: %v", err)
+		}
+	}
+
 	var snapshot Snapshot
 	if err := json.Unmarshal(data, &snapshot); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal snapshot: %v", err)
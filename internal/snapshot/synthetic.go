@@ -0,0 +1,79 @@
+package snapshot
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// syntheticKinds are the resource types synthetic snapshots are made of
+var syntheticKinds = []string{"apps/v1/Deployment", "v1/ConfigMap", "v1/Service", "v1/Secret"}
+
+// syntheticNamespaces is how many namespaces synthetic resources are spread over
+const syntheticNamespaces = 20
+
+// SyntheticPair generates a baseline of n resources and a current snapshot in which
+// about changeRatio of them were modified, and as many again were added or removed.
+// The snapshots are the same for the same arguments, so they suit benchmarking
+// the comparison and rendering without a cluster.
+func SyntheticPair(n int, changeRatio float64) (baseline, current *Snapshot) {
+	rng := rand.New(rand.NewSource(int64(n)))
+	now := time.Now().UTC()
+
+	baseline = &Snapshot{SchemaVersion: SchemaVersion, Timestamp: now.Add(-time.Minute), Resources: make(map[string]ResourceInfo, n)}
+	current = &Snapshot{SchemaVersion: SchemaVersion, Timestamp: now, Resources: make(map[string]ResourceInfo, n)}
+
+	for i := 0; i < n; i++ {
+		res := syntheticResource(i, now)
+		key := fmt.Sprintf("%s|%s|%s", res.GroupVersionKind, res.Namespace, res.Name)
+
+		switch roll := rng.Float64(); {
+		case roll < changeRatio/2:
+			// Removed
+			baseline.Resources[key] = res
+		case roll < changeRatio:
+			// Added
+			current.Resources[key] = res
+		case roll < changeRatio*2:
+			// Modified
+			baseline.Resources[key] = res
+			changed := res
+			changed.ResourceVersion = fmt.Sprint(i + n)
+			changed.SpecHash = fmt.Sprintf("%032x", i+n)
+			changed.Manifest = syntheticManifest(res.GroupVersionKind, res.Namespace, res.Name, 2)
+			current.Resources[key] = changed
+		default:
+			baseline.Resources[key] = res
+			current.Resources[key] = res
+		}
+	}
+	return baseline, current
+}
+
+// syntheticResource returns the i-th synthetic resource
+func syntheticResource(i int, now time.Time) ResourceInfo {
+	gvk := syntheticKinds[i%len(syntheticKinds)]
+	namespace := fmt.Sprintf("team-%02d", i%syntheticNamespaces)
+	name := fmt.Sprintf("app-%06d", i)
+
+	return ResourceInfo{
+		GroupVersionKind:  gvk,
+		Namespace:         namespace,
+		Name:              name,
+		UID:               fmt.Sprintf("00000000-0000-0000-0000-%012d", i),
+		ResourceVersion:   fmt.Sprint(i),
+		CreationTimestamp: now.Add(-time.Duration(i) * time.Minute),
+		SpecHash:          fmt.Sprintf("%032x", i),
+		Manifest:          syntheticManifest(gvk, namespace, name, 1),
+		Labels:            map[string]string{"app": name, "team": namespace},
+		Generation:        1,
+	}
+}
+
+// syntheticManifest returns a small manifest; replicas tells versions apart
+func syntheticManifest(gvk, namespace, name string, replicas int) string {
+	split := strings.LastIndex(gvk, "/")
+	return fmt.Sprintf("apiVersion: %s\nkind: %s\nmetadata:\n  name: %s\n  namespace: %s\nspec:\n  replicas: %d\n  template:\n    spec:\n      containers:\n      - name: app\n        image: example.com/%s:1.0\n",
+		gvk[:split], gvk[split+1:], name, namespace, replicas, name)
+}
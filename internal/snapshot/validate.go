@@ -0,0 +1,118 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Key returns the key the resource is stored under in Snapshot.Resources
+func (r ResourceInfo) Key() string {
+	return fmt.Sprintf("%s|%s|%s", r.GroupVersionKind, r.Namespace, r.Name)
+}
+
+// Validate decodes a saved snapshot like Decode and checks its structural
+// integrity. It returns an error if the snapshot can't be decoded at all (e.g.
+// a truncated file), and otherwise the snapshot with the problems found, if any.
+func Validate(data []byte) (*Snapshot, []string, error) {
+	snap, err := Decode(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// encoding/json keeps the last of duplicated keys, so look for them in the raw document
+	problems, err := duplicateResourceKeys(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snap, append(problems, snap.check()...), nil
+}
+
+// check reports the problems in a decoded snapshot
+func (s *Snapshot) check() []string {
+	var problems []string
+	if s.Timestamp.IsZero() {
+		problems = append(problems, "timestamp is missing")
+	}
+	if s.Resources == nil {
+		problems = append(problems, "resources are missing")
+	}
+	if len(s.FailedTypes) > 0 && !s.Partial {
+		problems = append(problems, fmt.Sprintf("%d failed resource types recorded but the snapshot isn't marked partial", len(s.FailedTypes)))
+	}
+	if len(s.UnlistedTypes) > 0 && !s.Partial {
+		problems = append(problems, fmt.Sprintf("%d unlisted resource types recorded but the snapshot isn't marked partial", len(s.UnlistedTypes)))
+	}
+	if len(s.NamespaceFailedTypes) > 0 && !s.Partial {
+		problems = append(problems, fmt.Sprintf("resource types that failed in %d namespaces recorded but the snapshot isn't marked partial", len(s.NamespaceFailedTypes)))
+	}
+
+	keys := make([]string, 0, len(s.Resources))
+	for key := range s.Resources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		res := s.Resources[key]
+		var missing []string
+		if res.GroupVersionKind == "" {
+			missing = append(missing, "groupVersionKind")
+		}
+		if res.Name == "" {
+			missing = append(missing, "name")
+		}
+		if res.SpecHash == "" {
+			missing = append(missing, "specHash")
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("resource %q: missing %s", key, strings.Join(missing, ", ")))
+		}
+		if want := res.Key(); key != want {
+			problems = append(problems, fmt.Sprintf("resource %q: key doesn't match its kind, namespace and name (%q)", key, want))
+		}
+	}
+	return problems
+}
+
+// duplicateResourceKeys reports the keys that appear more than once in the
+// resources object of a saved snapshot
+func duplicateResourceKeys(data []byte) ([]string, error) {
+	var doc struct {
+		Resources json.RawMessage `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot: %v", err)
+	}
+	if len(doc.Resources) == 0 || bytes.Equal(doc.Resources, []byte("null")) {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(doc.Resources))
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("failed to read resources: %v", err)
+	}
+
+	var problems []string
+	seen := make(map[string]int)
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read resources: %v", err)
+		}
+		key, _ := token.(string)
+
+		var skipped json.RawMessage
+		if err := dec.Decode(&skipped); err != nil {
+			return nil, fmt.Errorf("failed to read resource %q: %v", key, err)
+		}
+
+		seen[key]++
+		if seen[key] == 2 {
+			problems = append(problems, fmt.Sprintf("resource %q: key appears more than once; only the last entry is used", key))
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,104 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	internal_k8s "github.com/winson-sou/k8s-rdiff/internal/k8s"
+)
+
+// watchSyncTimeout bounds how long NewWatcher waits for the initial lists
+const watchSyncTimeout = 2 * time.Minute
+
+// Watcher keeps informer caches of the selected resources up to date, so
+// snapshots can be taken repeatedly without relisting the cluster. It suits
+// long-running continuous diffing; one-off captures should use Capture.
+type Watcher struct {
+	session       *captureSession
+	cache         *internal_k8s.Cache
+	resourceTypes []string
+	failedTypes   []string
+}
+
+// NewWatcher discovers the resource types selected by the options and starts
+// watching them until ctx is done. It returns once the initial lists are cached.
+// Field selectors, several Namespaces and ConfirmLimit aren't supported.
+func NewWatcher(ctx context.Context, opts CaptureOptions) (*Watcher, error) {
+	if opts.FieldSelector != "" {
+		return nil, errors.New("field selectors are not supported when watching")
+	}
+	if len(opts.Namespaces) > 0 {
+		return nil, errors.New("watching several namespaces is not supported; watch one namespace or all of them")
+	}
+
+	session, err := newCaptureSession(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceTypes, _, err := session.resourceTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := session.client.NewCache(resourceTypes, opts.Namespace, session.listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	failedTypes := cache.Start(ctx, watchSyncTimeout)
+	if ctx.Err() != nil {
+		return nil, ErrCaptureCancelled
+	}
+	if len(failedTypes) > 0 {
+		if opts.Strict {
+			return nil, fmt.Errorf("failed to list %d resource types (not allowed with --strict): %v", len(failedTypes), failedTypes)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: failed to list %d resource types, they won't be watched: %v\n", len(failedTypes), failedTypes)
+	}
+
+	return &Watcher{
+		session:       session,
+		cache:         cache,
+		resourceTypes: resourceTypes,
+		failedTypes:   failedTypes,
+	}, nil
+}
+
+// Changes returns a channel that receives a value whenever watched resources
+// changed. Bursts of events may be delivered as a single value.
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.cache.Changes()
+}
+
+// Snapshot builds a snapshot of the current cache contents. Exceeding
+// MaxResources returns the snapshot marked Partial together with an error
+// wrapping ErrResourceLimitExceeded.
+func (w *Watcher) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snapshot := w.session.newSnapshot(ctx)
+	snapshot.FailedTypes = append(snapshot.FailedTypes, w.failedTypes...)
+
+	for _, resourceType := range w.resourceTypes {
+		resources, err := w.cache.ListResources(resourceType)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resources {
+			w.session.addResource(snapshot, resource)
+		}
+	}
+
+	if limit := w.session.opts.MaxResources; limit > 0 && len(snapshot.Resources) > limit {
+		snapshot.Partial = true
+		return snapshot, fmt.Errorf("%w: %d objects are watched (limit %d); narrow the capture with --namespace, --api-group or --ignore, or raise --max-resources",
+			ErrResourceLimitExceeded, len(snapshot.Resources), limit)
+	}
+
+	if err := checkFailedTypes(snapshot, len(w.resourceTypes)); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
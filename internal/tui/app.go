@@ -1,6 +1,8 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -10,12 +12,14 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/winson-sou/k8s-rdiff/internal/diff"
+	"github.com/winson-sou/k8s-rdiff/internal/filter"
 	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 type state int
@@ -27,6 +31,8 @@ const (
 	stateCapturingCurrent
 	stateShowingDiff
 	stateShowingResourceDetail
+	stateShowingSummary
+	stateShowingExcluded
 	stateError
 )
 
@@ -34,6 +40,8 @@ const (
 type KeyMap struct {
 	Capture     key.Binding
 	Continue    key.Binding
+	Refresh     key.Binding
+	Retry       key.Binding
 	Back        key.Binding
 	Quit        key.Binding
 	ForceQuit   key.Binding
@@ -43,13 +51,25 @@ type KeyMap struct {
 	Down        key.Binding
 	PageUp      key.Binding
 	PageDown    key.Binding
+	Top         key.Binding
+	Bottom      key.Binding
 	Enter       key.Binding
+	NextOfKind  key.Binding
+	PrevOfKind  key.Binding
 	FilterAll   key.Binding
 	FilterAdded key.Binding
 	FilterRemoved key.Binding
 	FilterModified key.Binding
+	FilterLabels key.Binding
+	ExcludeKind  key.Binding
+	HideNamespace key.Binding
+	ViewNamespace key.Binding
+	ShowSummary  key.Binding
+	ShowExcluded key.Binding
+	ToggleLegend key.Binding
 	Escape      key.Binding
 	CopyYAML    key.Binding
+	ExportYAML  key.Binding
 }
 
 // ShortHelp returns keybindings to be shown in the mini help view.
@@ -60,10 +80,10 @@ func (k KeyMap) ShortHelp() []key.Binding {
 // FullHelp returns keybindings for the expanded help view.
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Capture, k.Continue, k.Back, k.Escape},
-		{k.Up, k.Down, k.PageUp, k.PageDown, k.Enter},
-		{k.FilterAll, k.FilterAdded, k.FilterRemoved, k.FilterModified},
-		{k.ToggleView, k.CopyYAML, k.Help, k.Quit, k.ForceQuit},
+		{k.Capture, k.Continue, k.Refresh, k.Back, k.Escape},
+		{k.Up, k.Down, k.PageUp, k.PageDown, k.Top, k.Bottom, k.Enter, k.NextOfKind, k.PrevOfKind},
+		{k.FilterAll, k.FilterAdded, k.FilterRemoved, k.FilterModified, k.FilterLabels, k.ExcludeKind, k.HideNamespace, k.ViewNamespace},
+		{k.ToggleView, k.ToggleLegend, k.ShowSummary, k.ShowExcluded, k.CopyYAML, k.ExportYAML, k.Help, k.Quit, k.ForceQuit},
 	}
 }
 
@@ -78,6 +98,14 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("c"),
 			key.WithHelp("c", "continue to capture current state"),
 		),
+		Refresh: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "refresh current state against the same baseline"),
+		),
+		Retry: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "retry the failed capture"),
+		),
 		Back: key.NewBinding(
 			key.WithKeys("b"),
 			key.WithHelp("b", "back"),
@@ -114,10 +142,26 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("pgdown"),
 			key.WithHelp("pgdown", "page down"),
 		),
+		Top: key.NewBinding(
+			key.WithKeys("g", "home"),
+			key.WithHelp("g/home", "go to top"),
+		),
+		Bottom: key.NewBinding(
+			key.WithKeys("G", "end"),
+			key.WithHelp("G/end", "go to bottom"),
+		),
 		Enter: key.NewBinding(
 			key.WithKeys("enter"),
 			key.WithHelp("enter", "view resource details"),
 		),
+		NextOfKind: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next resource of the selected kind"),
+		),
+		PrevOfKind: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "previous resource of the selected kind"),
+		),
 		FilterAll: key.NewBinding(
 			key.WithKeys("0"),
 			key.WithHelp("0", "show all resources"),
@@ -134,6 +178,35 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("3"),
 			key.WithHelp("3", "show modified resources"),
 		),
+		FilterLabels: key.NewBinding(
+			key.WithKeys("l"),
+			key.WithHelp("l", "filter by label selector"),
+		),
+		ExcludeKind: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "exclude the selected kind"),
+		),
+		HideNamespace: key.NewBinding(
+			key.WithKeys("h"),
+			key.WithHelp("h", "hide the selected namespace"),
+		),
+		ViewNamespace: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "only show the selected namespace (again to show all)"),
+		),
+		ToggleLegend: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "toggle color legend"),
+		),
+		ShowSummary: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "toggle change summary"),
+		),
+		ShowExcluded: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "toggle excluded resource types"),
+			key.WithDisabled(), // Enabled by --verbose
+		),
 		Escape: key.NewBinding(
 			key.WithKeys("esc"),
 			key.WithHelp("esc", "go back"),
@@ -142,6 +215,10 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("y"),
 			key.WithHelp("y", "copy YAML to clipboard"),
 		),
+		ExportYAML: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "export baseline and current YAML to files"),
+		),
 	}
 }
 
@@ -161,9 +238,8 @@ type Model struct {
 	spinner           spinner.Model
 	width             int
 	height            int
-	namespace         string
-	ignorePattern     string
-	kubeconfigPath    string
+	captureOpts       snapshot.CaptureOptions
+	compareOpts       diff.CompareOptions
 	baseline          *snapshot.Snapshot
 	current           *snapshot.Snapshot
 	diffResult        *diff.DiffResult
@@ -171,17 +247,57 @@ type Model struct {
 	viewport          viewport.Model
 	table             table.Model
 	error             error
+	failedCapture     state  // Capture that led to the error (stateCapturingBaseline or stateCapturingCurrent), for retrying; stateReady if none
 	showHelp          bool
-	outputFormat      string // table, yaml, json
+	showLegend        bool
+	outputFormat      string // table, yaml, json, tree
 	selectedResource  *diff.ResourceDiff
 	clusterInfo       string // Current cluster name and context
 	resourceFilter    FilterType // Current resource filter
 	statusMessage     string     // Message to display (e.g., "Copied to clipboard")
 	statusMessageTime time.Time  // When to hide the status message
+	resultSelector    labels.Selector // Label selector applied to the diff results
+	selectorInput     textinput.Model // Input for editing the label selector
+	editingSelector   bool            // Whether the label selector input is active
+	confirmQuit       bool            // Whether we're asking to confirm quitting mid-capture
+	useClipboard      bool            // Whether 'y' copies to the clipboard rather than a temp file
+	cancelCapture     context.CancelFunc // Cancels the capture in progress, if any
+	formats           []string        // Output formats the view toggle cycles through
+	limitPrompt       *resourceLimitMsg // Pending question whether to capture past --max-resources
+	captureProgress   *snapshot.CaptureProgress // Progress of the capture in progress, once known
+	excludedKinds     []string        // Resource types hidden from the diff for this session
+	hiddenNamespaces  []string        // Namespaces hidden from the diff view
+	viewNamespace     string          // Only namespace shown in the diff view; empty for all
+	maxPerKind        int             // Changes shown per kind before "… and N more"; 0 for no limit
+	focus             string          // --focus resource to open once the first diff is ready; cleared after
+	tree              treeView        // State of the tree view
+	tableRows         *tableRows      // Rows of the table view, built a chunk at a time
+	outputStream      *outputStream   // yaml/json output still being highlighted, if any
+	persistPrompt     string          // Excluded kind we're offering to save to the ignore file
+	capture           func(context.Context, snapshot.CaptureOptions) (*snapshot.Snapshot, error) // snapshot.Capture, or a fake in tests
+}
+
+// Options configures a new Model
+type Options struct {
+	Capture        snapshot.CaptureOptions
+	Compare        diff.CompareOptions
+	ResultSelector string   // Label selector (e.g. "team=payments") applied to the diff results
+	NoClipboard    bool     // Save copied YAML to a temp file instead of the clipboard
+	Formats        []string // Formats the view toggle cycles through (default table, yaml, json, tree)
+	HideNamespaces []string // Namespaces hidden from the diff view (they are still captured)
+	ViewNamespace  string   // Only namespace shown in the diff view, e.g. of an all-namespaces capture
+	MaxPerKind     int      // Changes shown per kind before "… and N more"; 0 for no limit
+	Focus          string   // KIND[/NAME] of a resource whose details open as soon as the diff is ready
+	Verbose        bool     // Enable the overlay listing the resource types the capture left out
+}
+
+// DefaultFormats are the output formats the view toggle cycles through by default
+func DefaultFormats() []string {
+	return []string{"table", "yaml", "json", "tree"}
 }
 
 // New returns a new instance of the application model
-func New(namespace, ignorePattern, kubeconfigPath string) Model {
+func New(opts Options) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
@@ -197,6 +313,7 @@ func New(namespace, ignorePattern, kubeconfigPath string) Model {
 		{Title: "NAME", Width: 30},
 		{Title: "RESOURCE VERSION", Width: 25},
 		{Title: "SPEC HASH", Width: 25},
+		{Title: "SEVERITY", Width: 10},
 	}
 
 	t := table.New(
@@ -207,19 +324,69 @@ func New(namespace, ignorePattern, kubeconfigPath string) Model {
 	
 	t.SetStyles(tableStyles())
 
+	ti := textinput.New()
+	ti.Prompt = "Label selector: "
+	ti.Placeholder = "team=payments,tier!=cache"
+
+	// The selector is validated by the CLI, so an invalid one here simply matches everything
+	selector, err := labels.Parse(opts.ResultSelector)
+	if err != nil {
+		selector = labels.Everything()
+	}
+
+	formats := opts.Formats
+	if len(formats) == 0 {
+		formats = DefaultFormats()
+	}
+
+	keyMap := DefaultKeyMap()
+	keyMap.ShowExcluded.SetEnabled(opts.Verbose)
+	keyMap.ToggleView.SetHelp("tab", fmt.Sprintf("toggle view (%s)", strings.Join(formats, "/")))
+	useClipboard := !opts.NoClipboard && clipboardAvailable()
+	if !useClipboard {
+		keyMap.CopyYAML.SetHelp("y", "save YAML to a temp file")
+	}
+
+	// The excluded types are listed by the 'v' overlay; logging them would garble the altscreen
+	opts.Capture.QuietExclusions = true
+
 	return Model{
-		state:          stateReady,
-		keyMap:         DefaultKeyMap(),
-		help:           h,
-		spinner:        s,
-		namespace:      namespace,
-		ignorePattern:  ignorePattern,
-		kubeconfigPath: kubeconfigPath,
-		showHelp:       true,
-		outputFormat:   "table",
-		table:          t,
-		resourceFilter: FilterAll,
+		state:            stateReady,
+		keyMap:           keyMap,
+		help:             h,
+		spinner:          s,
+		captureOpts:      opts.Capture,
+		compareOpts:      opts.Compare,
+		showHelp:         true,
+		showLegend:       true,
+		outputFormat:     formats[0],
+		table:            t,
+		resourceFilter:   FilterAll,
+		resultSelector:   selector,
+		selectorInput:    ti,
+		useClipboard:     useClipboard,
+		formats:          formats,
+		hiddenNamespaces: opts.HideNamespaces,
+		viewNamespace:    opts.ViewNamespace,
+		maxPerKind:       opts.MaxPerKind,
+		focus:            opts.Focus,
+		capture:          snapshot.Capture,
+	}
+}
+
+// nextFormat returns the format after the current one in the toggle cycle
+func (m Model) nextFormat() string {
+	for i, format := range m.formats {
+		if format == m.outputFormat {
+			return m.formats[(i+1)%len(m.formats)]
+		}
 	}
+	return m.formats[0]
+}
+
+// Snapshots returns the baseline and current snapshots held by the model (either may be nil)
+func (m Model) Snapshots() (baseline, current *snapshot.Snapshot) {
+	return m.baseline, m.current
 }
 
 // Init initializes the application
@@ -227,15 +394,97 @@ func (m Model) Init() tea.Cmd {
 	return nil
 }
 
-// Update handles application updates based on messages
+// errNoSnapshot is shown when a capture finished without a snapshot or an error
+var errNoSnapshot = errors.New("the capture returned no snapshot; press 'b' to go back and capture again")
+
+// Update handles application updates based on messages. States that show a
+// snapshot never end up without one: that's reported as an error instead.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	if model, ok := updated.(Model); ok && model.missingSnapshot() {
+		model.state = stateError
+		model.error = errNoSnapshot
+		updated = model
+	}
+	return updated, cmd
+}
+
+// missingSnapshot reports whether the current state needs a snapshot or diff
+// that isn't there
+func (m Model) missingSnapshot() bool {
+	switch m.state {
+	case stateBaselineCaptured:
+		return m.baseline == nil
+	case stateShowingDiff, stateShowingResourceDetail, stateShowingSummary, stateShowingExcluded:
+		return m.baseline == nil || m.current == nil || m.diffResult == nil
+	}
+	return false
+}
+
+// update handles a message; see Update
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// While confirming a quit, only the answer (or a second ctrl+c) matters
+		if m.confirmQuit {
+			switch {
+			case key.Matches(msg, m.keyMap.ForceQuit), msg.String() == "y", msg.String() == "Y":
+				return m, tea.Quit
+			case msg.String() == "n", msg.String() == "N", key.Matches(msg, m.keyMap.Escape):
+				m.confirmQuit = false
+			}
+			return m, nil
+		}
+
+		// While the capture waits to go past the resource limit, only the answer matters
+		if m.limitPrompt != nil && !key.Matches(msg, m.keyMap.ForceQuit) {
+			switch {
+			case msg.String() == "y", msg.String() == "Y":
+				m.limitPrompt.answer <- true
+			case msg.String() == "n", msg.String() == "N", key.Matches(msg, m.keyMap.Escape):
+				m.limitPrompt.answer <- false
+			default:
+				return m, nil
+			}
+			wait := m.limitPrompt.wait
+			m.limitPrompt = nil
+			return m, wait
+		}
+
+		// After excluding a kind, only the answer to saving it matters
+		if m.persistPrompt != "" && !key.Matches(msg, m.keyMap.ForceQuit) {
+			switch {
+			case msg.String() == "y", msg.String() == "Y":
+				if err := filter.AppendToIgnoreFile(filter.IgnoreFileName, m.persistPrompt); err != nil {
+					m.statusMessage = "✗ " + err.Error()
+				} else {
+					m.statusMessage = fmt.Sprintf("✓ Added %s to %s", m.persistPrompt, filter.IgnoreFileName)
+				}
+			case msg.String() == "n", msg.String() == "N", key.Matches(msg, m.keyMap.Escape):
+				m.statusMessage = fmt.Sprintf("Excluded %s for this session", m.persistPrompt)
+			default:
+				return m, nil
+			}
+			m.persistPrompt = ""
+			m.statusMessageTime = time.Now().Add(3 * time.Second)
+			return m, hideStatusMessageCmd(3)
+		}
+
+		// While editing the label selector, keys go to the text input
+		if m.editingSelector && !key.Matches(msg, m.keyMap.ForceQuit) {
+			return m.updateSelectorInput(msg)
+		}
+
 		switch {
 		case key.Matches(msg, m.keyMap.ForceQuit):
+			// Don't throw away a long-running capture on a stray ctrl+c
+			if m.isCapturing() {
+				m.confirmQuit = true
+				return m, nil
+			}
 			return m, tea.Quit
 
 		case key.Matches(msg, m.keyMap.Quit):
@@ -244,17 +493,53 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.state = stateShowingDiff
 				return m, nil
 			}
+			if m.state == stateShowingSummary || m.state == stateShowingExcluded {
+				m.closeSummary()
+				return m, nil
+			}
 			
-			if m.state != stateCapturingBaseline && m.state != stateCapturingCurrent {
+			if !m.isCapturing() {
 				return m, tea.Quit
 			}
 
+		case key.Matches(msg, m.keyMap.Escape) && m.isCapturing():
+			// Stop listing; the capture reports back with whatever it collected
+			if m.cancelCapture != nil {
+				m.cancelCapture()
+				m.statusMessage = "Cancelling capture..."
+			}
+			return m, nil
+
 		case key.Matches(msg, m.keyMap.Escape) && m.state == stateShowingResourceDetail:
 			// Return to diff view when pressing ESC in resource detail view
 			m.state = stateShowingDiff
 			m.selectedResource = nil
 			return m, nil
 
+		case key.Matches(msg, m.keyMap.Escape) && (m.state == stateShowingSummary || m.state == stateShowingExcluded):
+			m.closeSummary()
+			return m, nil
+
+		case key.Matches(msg, m.keyMap.ShowSummary) && m.state == stateShowingDiff:
+			m.state = stateShowingSummary
+			m.viewport.SetContent(renderSummary(m.visibleDiff()))
+			m.viewport.GotoTop()
+			return m, nil
+
+		case key.Matches(msg, m.keyMap.ShowSummary) && m.state == stateShowingSummary:
+			m.closeSummary()
+			return m, nil
+
+		case key.Matches(msg, m.keyMap.ShowExcluded) && m.state == stateShowingDiff:
+			m.state = stateShowingExcluded
+			m.viewport.SetContent(renderExcluded(m.current))
+			m.viewport.GotoTop()
+			return m, nil
+
+		case key.Matches(msg, m.keyMap.ShowExcluded) && m.state == stateShowingExcluded:
+			m.closeSummary()
+			return m, nil
+
 		case key.Matches(msg, m.keyMap.CopyYAML):
 			if m.state == stateShowingResourceDetail && m.selectedResource != nil {
 				var yamlManifest string
@@ -280,15 +565,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				
 				if yamlManifest != "" {
-					if err := clipboard.WriteAll(yamlManifest); err == nil {
-						m.statusMessage = "✓ YAML copied to clipboard"
-						m.statusMessageTime = time.Now().Add(3 * time.Second)
-						return m, hideStatusMessageCmd(3)
+					if m.useClipboard {
+						if err := clipboard.WriteAll(yamlManifest); err == nil {
+							m.statusMessage = "✓ YAML copied to clipboard"
+							m.statusMessageTime = time.Now().Add(3 * time.Second)
+							return m, hideStatusMessageCmd(3)
+						}
+					}
+
+					// No usable clipboard, so save the manifest where the user can pick it up
+					path, err := writeManifestToTempFile(m.selectedResource.Resource.Name, yamlManifest)
+					if err != nil {
+						m.statusMessage = "✗ Failed to save YAML: " + err.Error()
 					} else {
-						m.statusMessage = "✗ Failed to copy to clipboard: " + err.Error()
-						m.statusMessageTime = time.Now().Add(3 * time.Second)
-						return m, hideStatusMessageCmd(3)
+						m.statusMessage = "✓ YAML written to " + path
 					}
+					m.statusMessageTime = time.Now().Add(5 * time.Second)
+					return m, hideStatusMessageCmd(5)
 				} else {
 					m.statusMessage = "✗ No YAML manifest available to copy"
 					m.statusMessageTime = time.Now().Add(3 * time.Second)
@@ -296,22 +589,39 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+		case key.Matches(msg, m.keyMap.ExportYAML) && m.state == stateShowingResourceDetail && m.selectedResource != nil:
+			var baselineManifest, currentManifest string
+			if m.selectedResource.BaselineResource != nil {
+				baselineManifest = m.selectedResource.BaselineResource.Manifest
+			}
+			if m.selectedResource.CurrentResource != nil {
+				currentManifest = m.selectedResource.CurrentResource.Manifest
+			}
+
+			paths, err := exportManifests(m.selectedResource.Resource.Name, baselineManifest, currentManifest)
+			switch {
+			case err != nil:
+				m.statusMessage = "✗ " + err.Error()
+			case len(paths) == 0:
+				m.statusMessage = "✗ No YAML manifests available to export"
+			default:
+				m.statusMessage = "✓ Wrote " + strings.Join(paths, " and ")
+			}
+			m.statusMessageTime = time.Now().Add(5 * time.Second)
+			return m, hideStatusMessageCmd(5)
+
 		case key.Matches(msg, m.keyMap.Help):
 			m.showHelp = !m.showHelp
 
+		case key.Matches(msg, m.keyMap.ToggleLegend) && m.state == stateShowingDiff:
+			m.showLegend = !m.showLegend
+
 		case key.Matches(msg, m.keyMap.ToggleView) && m.state == stateShowingDiff:
-			switch m.outputFormat {
-			case "table":
-				m.outputFormat = "yaml"
-				cmd = m.updateDiffOutputCmd()
-			case "yaml":
-				m.outputFormat = "json"
-				cmd = m.updateDiffOutputCmd()
-			case "json":
-				m.outputFormat = "table"
+			if next := m.nextFormat(); next != m.outputFormat {
+				m.outputFormat = next
 				cmd = m.updateDiffOutputCmd()
+				cmds = append(cmds, cmd)
 			}
-			cmds = append(cmds, cmd)
 			
 		// Resource filtering keys
 		case key.Matches(msg, m.keyMap.FilterAll) && m.state == stateShowingDiff:
@@ -342,14 +652,67 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmds = append(cmds, cmd)
 			}
 
+		case key.Matches(msg, m.keyMap.ExcludeKind) && m.state == stateShowingDiff && m.outputFormat == "table":
+			selectedRow := m.table.SelectedRow()
+			if len(selectedRow) < 2 {
+				break
+			}
+
+			m.excludedKinds = append(m.excludedKinds, selectedRow[1])
+			m.persistPrompt = selectedRow[1]
+			cmds = append(cmds, m.updateDiffOutputCmd())
+
+		case key.Matches(msg, m.keyMap.HideNamespace) && m.state == stateShowingDiff && m.outputFormat == "table":
+			selectedRow := m.table.SelectedRow()
+			if len(selectedRow) < 3 || selectedRow[2] == "" {
+				break
+			}
+
+			m.hiddenNamespaces = append(m.hiddenNamespaces, selectedRow[2])
+			m.statusMessage = fmt.Sprintf("Hid namespace %s", selectedRow[2])
+			m.statusMessageTime = time.Now().Add(3 * time.Second)
+			cmds = append(cmds, m.updateDiffOutputCmd(), hideStatusMessageCmd(3))
+
+		case key.Matches(msg, m.keyMap.ViewNamespace) && m.state == stateShowingDiff && m.outputFormat == "table":
+			// Narrow the view to the selected row's namespace, or widen it back to all
+			if m.viewNamespace != "" {
+				m.statusMessage = fmt.Sprintf("Showing all namespaces (was %s)", m.viewNamespace)
+				m.viewNamespace = ""
+			} else {
+				selectedRow := m.table.SelectedRow()
+				if len(selectedRow) < 3 || selectedRow[2] == "" {
+					break
+				}
+				m.viewNamespace = selectedRow[2]
+				m.statusMessage = fmt.Sprintf("Only showing namespace %s", m.viewNamespace)
+			}
+			m.statusMessageTime = time.Now().Add(3 * time.Second)
+			cmds = append(cmds, m.updateDiffOutputCmd(), hideStatusMessageCmd(3))
+
+		case key.Matches(msg, m.keyMap.NextOfKind) && m.state == stateShowingDiff && m.outputFormat == "table":
+			if !m.jumpToKind(1) {
+				cmds = append(cmds, m.noOtherOfKindCmd())
+			}
+
+		case key.Matches(msg, m.keyMap.PrevOfKind) && m.state == stateShowingDiff && m.outputFormat == "table":
+			if !m.jumpToKind(-1) {
+				cmds = append(cmds, m.noOtherOfKindCmd())
+			}
+
+		case key.Matches(msg, m.keyMap.FilterLabels) && m.state == stateShowingDiff:
+			m.editingSelector = true
+			m.selectorInput.SetValue(m.resultSelector.String())
+			m.selectorInput.CursorEnd()
+			cmds = append(cmds, m.selectorInput.Focus())
+
 		case key.Matches(msg, m.keyMap.Capture) && m.state == stateReady:
 			m.state = stateCapturingBaseline
-			cmd = m.captureBaselineCmd()
+			cmd = m.captureBaselineCmd(m.newCaptureContext())
 			cmds = append(cmds, cmd, m.spinner.Tick)
 
 		case key.Matches(msg, m.keyMap.Continue) && m.state == stateBaselineCaptured:
 			m.state = stateCapturingCurrent
-			cmd = m.captureCurrentStateCmd()
+			cmd = m.captureCurrentStateCmd(m.newCaptureContext())
 			cmds = append(cmds, cmd, m.spinner.Tick)
 
 		case key.Matches(msg, m.keyMap.Continue) && m.state == stateShowingDiff:
@@ -361,7 +724,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			
 			// Start capturing the new snapshot
 			m.state = stateCapturingCurrent
-			cmd = m.captureCurrentStateCmd()
+			cmd = m.captureCurrentStateCmd(m.newCaptureContext())
+			cmds = append(cmds, cmd, m.spinner.Tick)
+
+		case key.Matches(msg, m.keyMap.Retry) && m.state == stateError && m.failedCapture != stateReady:
+			// Run the failed capture again, e.g. after a transient auth or network error
+			m.error = nil
+			m.state = m.failedCapture
+			m.failedCapture = stateReady
+			if m.state == stateCapturingBaseline {
+				cmd = m.captureBaselineCmd(m.newCaptureContext())
+			} else {
+				cmd = m.captureCurrentStateCmd(m.newCaptureContext())
+			}
+			cmds = append(cmds, cmd, m.spinner.Tick)
+
+		case key.Matches(msg, m.keyMap.Refresh) && m.state == stateShowingDiff:
+			// Unlike 'c', keep the baseline so repeated refreshes track progress against it
+			m.current = nil
+			m.diffResult = nil
+			m.diffOutput = ""
+
+			m.state = stateCapturingCurrent
+			cmd = m.captureCurrentStateCmd(m.newCaptureContext())
 			cmds = append(cmds, cmd, m.spinner.Tick)
 
 		case key.Matches(msg, m.keyMap.Back):
@@ -377,9 +762,45 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case stateShowingResourceDetail:
 				m.state = stateShowingDiff
 				m.selectedResource = nil
+				if m.outputFormat == "tree" {
+					m.syncTree()
+				} else if m.outputFormat != "table" {
+					// Details opened by --focus replaced the yaml/json output
+					m.viewport.SetContent(m.diffOutput)
+					m.viewport.GotoTop()
+				}
+			case stateShowingSummary, stateShowingExcluded:
+				m.closeSummary()
+			case stateError:
+				// Keep a usable baseline so only the failed capture has to be redone
+				m.error = nil
+				m.failedCapture = stateReady
+				m.current = nil
+				m.diffResult = nil
+				m.diffOutput = ""
+				if m.baseline != nil {
+					m.state = stateBaselineCaptured
+				} else {
+					m.state = stateReady
+				}
 			}
 			
 		// Add enter key to view resource details
+		case key.Matches(msg, m.keyMap.Enter) && m.state == stateShowingDiff && m.outputFormat == "tree":
+			line := m.tree.selected()
+			if line == nil {
+				break
+			}
+			if line.resource == nil {
+				m.tree.toggle()
+				m.syncTree()
+				break
+			}
+
+			m.selectedResource = line.resource
+			m.state = stateShowingResourceDetail
+			cmds = append(cmds, m.loadResourceDetailCmd())
+
 		case key.Matches(msg, m.keyMap.Enter) && m.state == stateShowingDiff && m.outputFormat == "table":
 			// Check if we have a valid selection
 			if !m.table.Focused() {
@@ -412,6 +833,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					
 				case key.Matches(msg, m.keyMap.Down):
 					m.table.MoveDown(1)
+					m.extendTableRows(false)
 					return m, nil
 					
 				case key.Matches(msg, m.keyMap.PageUp):
@@ -420,7 +842,38 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					
 				case key.Matches(msg, m.keyMap.PageDown):
 					m.table.MoveDown(10)
+					m.extendTableRows(false)
 					return m, nil
+
+				case key.Matches(msg, m.keyMap.Top):
+					m.table.GotoTop()
+					return m, nil
+
+				case key.Matches(msg, m.keyMap.Bottom):
+					m.extendTableRows(true)
+					m.table.GotoBottom()
+					return m, nil
+				}
+			} else if m.outputFormat == "tree" {
+				// Tree navigation moves the cursor; the viewport follows it
+				delta := 0
+				switch {
+				case key.Matches(msg, m.keyMap.Up):
+					delta = -1
+				case key.Matches(msg, m.keyMap.Down):
+					delta = 1
+				case key.Matches(msg, m.keyMap.PageUp):
+					delta = -10
+				case key.Matches(msg, m.keyMap.PageDown):
+					delta = 10
+				case key.Matches(msg, m.keyMap.Top):
+					delta = -m.tree.cursor
+				case key.Matches(msg, m.keyMap.Bottom):
+					delta = len(m.tree.lines())
+				}
+				if delta != 0 {
+					m.tree.move(delta)
+					m.syncTree()
 				}
 			} else {
 				// Viewport navigation for yaml/json views
@@ -436,10 +889,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					
 				case key.Matches(msg, m.keyMap.PageDown):
 					m.viewport.PageDown()
+
+				case key.Matches(msg, m.keyMap.Top):
+					m.viewport.GotoTop()
+
+				case key.Matches(msg, m.keyMap.Bottom):
+					m.viewport.GotoBottom()
 				}
 			}
-		} else if m.state == stateShowingResourceDetail {
-			// Viewport navigation for resource detail view
+		} else if m.state == stateShowingResourceDetail || m.state == stateShowingSummary || m.state == stateShowingExcluded {
+			// Viewport navigation for resource detail, summary and excluded types views
 			switch {
 			case key.Matches(msg, m.keyMap.Up):
 				m.viewport.LineUp(1)
@@ -452,6 +911,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				
 			case key.Matches(msg, m.keyMap.PageDown):
 				m.viewport.PageDown()
+
+			case key.Matches(msg, m.keyMap.Top):
+				m.viewport.GotoTop()
+
+			case key.Matches(msg, m.keyMap.Bottom):
+				m.viewport.GotoBottom()
 			}
 		}
 
@@ -486,7 +951,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		
 		// Restore table content if we're showing diff
 		if m.state == stateShowingDiff && m.diffResult != nil && m.outputFormat == "table" {
-			m.table.SetRows(buildTableRows(m.diffResult))
+			m.setTableRows(m.filteredTableRows())
+		}
+		if m.state == stateShowingDiff && m.outputFormat == "tree" {
+			m.syncTree()
 		}
 		
 		m.viewport, cmd = m.viewport.Update(msg)
@@ -496,52 +964,138 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.help.Width = msg.Width
 
 	case spinner.TickMsg:
-		if m.state == stateCapturingBaseline || m.state == stateCapturingCurrent {
+		if m.isCapturing() {
 			m.spinner, cmd = m.spinner.Update(msg)
 			cmds = append(cmds, cmd)
 		}
 
+	case resourceLimitMsg:
+		m.limitPrompt = &msg
+
+	case captureProgressMsg:
+		if m.isCapturing() {
+			m.captureProgress = &msg.progress
+		}
+		cmds = append(cmds, msg.wait)
+
 	case baselineCapturedMsg:
-		m.state = stateBaselineCaptured
-		m.baseline = msg.snapshot
+		m.cancelCapture = nil
+		m.captureProgress = nil
+		m.statusMessage = ""
+		if errors.Is(msg.err, snapshot.ErrCaptureCancelled) {
+			// A partial baseline would make everything it missed look added, so start over
+			m.state = stateReady
+			m.statusMessage = "Baseline capture cancelled"
+			m.statusMessageTime = time.Now().Add(3 * time.Second)
+			return m, hideStatusMessageCmd(3)
+		}
+		if errors.Is(msg.err, snapshot.ErrResourceLimitExceeded) {
+			m.state = stateReady
+			m.statusMessage = "Baseline capture stopped: " + msg.err.Error()
+			return m, nil
+		}
+
+		if msg.err == nil && msg.snapshot == nil {
+			msg.err = errNoSnapshot
+		}
+
+		// A failed capture goes straight to the error, keeping no baseline
 		if msg.err != nil {
 			m.state = stateError
-			m.error = msg.err
+			m.error = fmt.Errorf("baseline capture failed: %w", msg.err)
+			m.failedCapture = stateCapturingBaseline
+			return m, nil
 		}
 
+		m.baseline = msg.snapshot
+		m.state = stateBaselineCaptured
+
 	case currentStateCapturedMsg:
+		m.cancelCapture = nil
+		m.captureProgress = nil
+		m.statusMessage = ""
+
+		// A cancelled capture still diffs what it collected; the view warns it's partial
+		if errors.Is(msg.err, snapshot.ErrCaptureCancelled) && msg.snapshot != nil {
+			msg.err = nil
+		}
+
+		if msg.err == nil && msg.snapshot == nil {
+			msg.err = errNoSnapshot
+		}
+
 		if msg.err != nil {
 			m.state = stateError
 			m.error = msg.err
+			m.failedCapture = stateCapturingCurrent
 		} else {
 			m.current = msg.snapshot
 			m.state = stateShowingDiff
 			
 			// Compare snapshots
-			m.diffResult = diff.Compare(m.baseline, m.current)
+			m.diffResult = diff.CompareWithOptions(m.baseline, m.current, m.compareOpts)
 			cmd = m.updateDiffOutputCmd()
 			cmds = append(cmds, cmd)
+
+			// Open the --focus resource straight away, once
+			if m.focus != "" {
+				if resourceDiff := m.focusedResource(); resourceDiff != nil {
+					m.selectedResource = resourceDiff
+					m.state = stateShowingResourceDetail
+					cmds = append(cmds, m.loadResourceDetailCmd())
+				} else {
+					m.statusMessage = fmt.Sprintf("✗ --focus: no %s in the diff", m.focus)
+					m.statusMessageTime = time.Now().Add(10 * time.Second)
+					cmds = append(cmds, hideStatusMessageCmd(10))
+				}
+				m.focus = ""
+			}
 		}
 
 	case diffOutputUpdatedMsg:
 		m.diffOutput = msg.output
+		m.outputStream = msg.stream
+		if msg.stream != nil {
+			cmds = append(cmds, streamOutputCmd(msg.stream))
+		}
 		
 		// For table view, update the table with filtered resources
 		if m.outputFormat == "table" {
 			cmd = m.updateTableWithFilterCmd()
 			cmds = append(cmds, cmd)
-		} else {
+		} else if m.outputFormat == "tree" {
+			m.tree = newTreeView(m.renderedDiff(), m.tree)
+			if m.state != stateShowingResourceDetail {
+				m.syncTree()
+			}
+		} else if m.state != stateShowingResourceDetail {
 			// For YAML/JSON view, update the viewport
 			m.viewport.SetContent(m.diffOutput)
 			m.viewport.GotoTop()
 		}
 
+	case outputChunkMsg:
+		// Drop chunks of output that has since been replaced
+		if msg.stream != m.outputStream || m.diffResult == nil {
+			break
+		}
+
+		m.diffOutput += msg.chunk
+		if m.state == stateShowingDiff && m.outputFormat != "table" && m.outputFormat != "tree" {
+			m.viewport.SetContent(m.diffOutput)
+		}
+		if !msg.stream.done() {
+			cmds = append(cmds, streamOutputCmd(msg.stream))
+		} else {
+			m.outputStream = nil
+		}
+
 	case resourceDetailLoadedMsg:
 		m.viewport.SetContent(msg.output)
 		m.viewport.GotoTop()
 
 	case tableUpdatedMsg:
-		m.table.SetRows(msg.rows)
+		m.setTableRows(msg.rows)
 
 	case clearStatusMessageMsg:
 		m.statusMessage = ""
@@ -550,47 +1104,54 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
-// Helper function to build table rows from diff result
-func buildTableRows(diffResult *diff.DiffResult) []table.Row {
-	var rows []table.Row
-	
-	// Add rows for added resources
-	for _, res := range diffResult.Added {
-		rows = append(rows, table.Row{
-			"Added",
-			res.Resource.GroupVersionKind,
-			res.Resource.Namespace,
-			res.Resource.Name,
-			res.Resource.ResourceVersion,
-			res.Resource.SpecHash,
-		})
+// closeSummary returns from the summary or excluded types view to the diff view,
+// restoring the yaml/json output they replaced in the viewport
+func (m *Model) closeSummary() {
+	m.state = stateShowingDiff
+	if m.outputFormat == "tree" {
+		m.syncTree()
+	} else if m.outputFormat != "table" {
+		m.viewport.SetContent(m.diffOutput)
+		m.viewport.GotoTop()
 	}
-	
-	// Add rows for removed resources
-	for _, res := range diffResult.Removed {
-		rows = append(rows, table.Row{
-			"Removed",
-			res.Resource.GroupVersionKind,
-			res.Resource.Namespace,
-			res.Resource.Name,
-			res.Resource.ResourceVersion,
-			res.Resource.SpecHash,
-		})
+}
+
+// jumpToKind moves the table cursor to the next (step 1) or previous (step -1) row
+// of the selected row's kind, wrapping around. It reports whether there was one.
+func (m *Model) jumpToKind(step int) bool {
+	m.extendTableRows(true)
+	rows := m.table.Rows()
+	cursor := m.table.Cursor()
+	if cursor < 0 || cursor >= len(rows) || len(rows[cursor]) < 2 {
+		return false
 	}
-	
-	// Add rows for modified resources
-	for _, res := range diffResult.Modified {
-		rows = append(rows, table.Row{
-			"Modified",
-			res.Resource.GroupVersionKind,
-			res.Resource.Namespace,
-			res.Resource.Name,
-			fmt.Sprintf("%s → %s", res.OldResourceVersion, res.NewResourceVersion),
-			fmt.Sprintf("%s → %s", res.OldSpecHash, res.NewSpecHash),
-		})
+
+	kind := rows[cursor][1]
+	for i := 1; i < len(rows); i++ {
+		row := ((cursor+step*i)%len(rows) + len(rows)) % len(rows)
+		if len(rows[row]) > 1 && rows[row][1] == kind {
+			m.table.SetCursor(row)
+			return true
+		}
 	}
-	
-	return rows
+	return false
+}
+
+// noOtherOfKindCmd reports that the selected row is the only one of its kind
+func (m *Model) noOtherOfKindCmd() tea.Cmd {
+	selectedRow := m.table.SelectedRow()
+	if len(selectedRow) < 2 {
+		return nil
+	}
+
+	m.statusMessage = fmt.Sprintf("No other %s resources", selectedRow[1])
+	m.statusMessageTime = time.Now().Add(3 * time.Second)
+	return hideStatusMessageCmd(3)
+}
+
+// isCapturing reports whether a snapshot capture is in progress
+func (m Model) isCapturing() bool {
+	return m.state == stateCapturingBaseline || m.state == stateCapturingCurrent
 }
 
 // Helper function to adjust column widths based on available space
@@ -603,6 +1164,7 @@ func adjustColumnWidths(columns []table.Column, totalWidth int) []table.Column {
 		15,  // Name
 		15,  // Resource Version
 		15,  // Spec Hash
+		8,   // Severity
 	}
 	
 	// Define flex factors (how much each column can grow)
@@ -613,6 +1175,7 @@ func adjustColumnWidths(columns []table.Column, totalWidth int) []table.Column {
 		3,  // Name
 		2,  // Resource Version
 		2,  // Spec Hash
+		0,  // Severity
 	}
 	
 	// Calculate total minimum width
@@ -696,6 +1259,37 @@ func (m Model) findSelectedResource(selectedRow table.Row) *diff.ResourceDiff {
 	return nil
 }
 
+// ParseFocus splits a --focus value into a kind name and an optional resource name
+func ParseFocus(focus string) (kind, name string, err error) {
+	parts := strings.Split(focus, "/")
+	if len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && parts[1] == "") {
+		return "", "", fmt.Errorf("invalid focus %q: expected KIND or KIND/NAME, e.g. Deployment/web", focus)
+	}
+	if len(parts) == 2 {
+		name = parts[1]
+	}
+	return parts[0], name, nil
+}
+
+// focusedResource returns the first change matching --focus, preferring modified
+// resources over added and removed ones, or nil if none matches
+func (m Model) focusedResource() *diff.ResourceDiff {
+	kind, name, err := ParseFocus(m.focus)
+	if err != nil || m.diffResult == nil {
+		return nil
+	}
+
+	for _, group := range [][]diff.ResourceDiff{m.diffResult.Modified, m.diffResult.Added, m.diffResult.Removed} {
+		for i, res := range group {
+			gvk := res.Resource.GroupVersionKind
+			if strings.EqualFold(gvk[strings.LastIndex(gvk, "/")+1:], kind) && (name == "" || res.Resource.Name == name) {
+				return &group[i]
+			}
+		}
+	}
+	return nil
+}
+
 // Command to load resource detail
 func (m Model) loadResourceDetailCmd() tea.Cmd {
 	return func() tea.Msg {
@@ -708,6 +1302,37 @@ func (m Model) loadResourceDetailCmd() tea.Cmd {
 		detailOutput.WriteString(fmt.Sprintf("Kind: %s\n", m.selectedResource.Resource.GroupVersionKind))
 		detailOutput.WriteString(fmt.Sprintf("Name: %s\n", m.selectedResource.Resource.Name))
 		detailOutput.WriteString(fmt.Sprintf("Namespace: %s\n", m.selectedResource.Resource.Namespace))
+		if changed, known := m.selectedResource.GenerationChanged(); known {
+			if changed {
+				detailOutput.WriteString(fmt.Sprintf("Generation: %d → %d (spec changed)\n", m.selectedResource.OldGeneration, m.selectedResource.NewGeneration))
+			} else {
+				detailOutput.WriteString(fmt.Sprintf("Generation: %d (unchanged, so the spec likely wasn't)\n", m.selectedResource.NewGeneration))
+			}
+		}
+		if fields := m.selectedResource.ImmutableFields; len(fields) > 0 {
+			immutableStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
+			detailOutput.WriteString(immutableStyle.Render(fmt.Sprintf(
+				"⚠ Immutable field changed: %s (applying this requires recreating the resource)", strings.Join(fields, ", "))) + "\n")
+		}
+		if changes := m.selectedResource.DataKeyChanges(); len(changes) > 0 {
+			keys := make([]string, len(changes))
+			for i, change := range changes {
+				keys[i] = fmt.Sprintf("%s (%s)", change.Key, strings.ToLower(string(change.Type)))
+			}
+			detailOutput.WriteString(fmt.Sprintf("Changed keys: %s\n", strings.Join(keys, ", ")))
+		}
+		if changes := m.selectedResource.RBACChanges(); len(changes) > 0 {
+			grantedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+			revokedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+			detailOutput.WriteString("Permission changes:\n")
+			for _, change := range changes {
+				style := grantedStyle
+				if change.Type == diff.Removed {
+					style = revokedStyle
+				}
+				detailOutput.WriteString("  " + style.Render(change.String()) + "\n")
+			}
+		}
 		detailOutput.WriteString("---\n\n")
 		
 		// If it's an added or removed resource, just show the manifest
@@ -725,6 +1350,7 @@ func (m Model) loadResourceDetailCmd() tea.Cmd {
 		} else if m.selectedResource.IsPresentInBaseline() {
 			// Removed resource
 			detailOutput.WriteString("## Removed Resource (Baseline Manifest)\n\n")
+			detailOutput.WriteString(removedResourceHeader(m.selectedResource.BaselineResource, m.baseline))
 			detailOutput.WriteString(m.selectedResource.BaselineResource.Manifest)
 		} else {
 			// Added resource
@@ -736,149 +1362,165 @@ func (m Model) loadResourceDetailCmd() tea.Cmd {
 	}
 }
 
+// removedResourceHeader describes how old a removed resource was when the baseline
+// last saw it, to tell long-lived objects from ephemeral ones
+func removedResourceHeader(resource *snapshot.ResourceInfo, baseline *snapshot.Snapshot) string {
+	var header strings.Builder
+
+	if resource.CreationTimestamp.IsZero() {
+		header.WriteString("Created:  unknown\n")
+	} else {
+		header.WriteString(fmt.Sprintf("Created:  %s", resource.CreationTimestamp.Format(time.RFC3339)))
+		if baseline != nil {
+			header.WriteString(fmt.Sprintf(" (age %s at baseline)", diff.FormatAge(baseline.Timestamp.Sub(resource.CreationTimestamp))))
+		}
+		header.WriteString("\n")
+	}
+
+	if baseline != nil {
+		header.WriteString(fmt.Sprintf("Baseline: %s\n", baseline.Timestamp.Format(time.RFC3339)))
+	}
+	header.WriteString("\n")
+
+	return header.String()
+}
+
 type resourceDetailLoadedMsg struct {
 	output string
 }
 
 // generateYAMLDiff creates a simple text diff between two YAML documents
 func generateYAMLDiff(oldYAML, newYAML string) string {
-	dmp := diffmatchpatch.New()
-	diffs := dmp.DiffMain(oldYAML, newYAML, false)
-	
-	var result strings.Builder
-	
-	for _, diff := range diffs {
-		switch diff.Type {
-		case diffmatchpatch.DiffDelete:
-			// Split the lines to add - prefix
-			lines := strings.Split(diff.Text, "\n")
-			for _, line := range lines {
-				if line != "" {
-					result.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(fmt.Sprintf("- %s\n", line)))
-				}
-			}
-		case diffmatchpatch.DiffInsert:
-			// Split the lines to add + prefix
-			lines := strings.Split(diff.Text, "\n")
-			for _, line := range lines {
-				if line != "" {
-					result.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render(fmt.Sprintf("+ %s\n", line)))
-				}
-			}
-		case diffmatchpatch.DiffEqual:
-			// Add context lines without prefix
-			result.WriteString(diff.Text)
+	return diff.ManifestDiff(oldYAML, newYAML, func(change diff.DiffType, line string) string {
+		color := lipgloss.Color("10")
+		if change == diff.Removed {
+			color = lipgloss.Color("9")
 		}
-	}
-	
-	return result.String()
+		return lipgloss.NewStyle().Foreground(color).Render(line)
+	})
 }
 
-// New command to update table with filtered resources
-func (m Model) updateTableWithFilterCmd() tea.Cmd {
-	return func() tea.Msg {
-		if m.diffResult == nil {
-			return nil
-		}
-		
-		var rows []table.Row
-		
-		// Filter resources based on the current filter
-		switch m.resourceFilter {
-		case FilterAll:
-			rows = buildTableRows(m.diffResult)
-		case FilterAdded:
-			rows = buildTableRowsForAddedOnly(m.diffResult)
-		case FilterRemoved:
-			rows = buildTableRowsForRemovedOnly(m.diffResult)
-		case FilterModified:
-			rows = buildTableRowsForModifiedOnly(m.diffResult)
+// updateSelectorInput handles key presses while the label selector input is active
+func (m Model) updateSelectorInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		selector, err := labels.Parse(m.selectorInput.Value())
+		if err != nil {
+			m.statusMessage = "✗ Invalid label selector: " + err.Error()
+			m.statusMessageTime = time.Now().Add(3 * time.Second)
+			return m, hideStatusMessageCmd(3)
 		}
-		
-		return tableUpdatedMsg{rows: rows}
+
+		m.resultSelector = selector
+		m.editingSelector = false
+		m.selectorInput.Blur()
+		return m, m.updateDiffOutputCmd()
+
+	case tea.KeyEsc:
+		m.editingSelector = false
+		m.selectorInput.Blur()
+		return m, nil
 	}
+
+	var cmd tea.Cmd
+	m.selectorInput, cmd = m.selectorInput.Update(msg)
+	return m, cmd
 }
 
-type tableUpdatedMsg struct {
-	rows []table.Row
+// visibleDiff returns the diff result narrowed by the view-time filters
+func (m Model) visibleDiff() *diff.DiffResult {
+	if m.diffResult == nil {
+		return nil
+	}
+
+	visible := m.diffResult.ExcludeKinds(m.excludedKinds).ExcludeNamespaces(m.hiddenNamespaces).FilterByLabels(m.resultSelector)
+	if m.viewNamespace != "" {
+		visible = visible.Filter(diff.FilterOptions{Namespaces: []string{m.viewNamespace}})
+	}
+	return visible
 }
 
-// Helper functions to build filtered table rows
-func buildTableRowsForAddedOnly(diffResult *diff.DiffResult) []table.Row {
-	var rows []table.Row
-	
-	// Add rows for added resources only
-	for _, res := range diffResult.Added {
-		rows = append(rows, table.Row{
-			"Added",
-			res.Resource.GroupVersionKind,
-			res.Resource.Namespace,
-			res.Resource.Name,
-			res.Resource.ResourceVersion,
-			res.Resource.SpecHash,
-		})
+// renderedDiff returns the visible diff capped to --max-per-kind changes per kind,
+// which keeps the views responsive when one kind has a huge number of changes
+func (m Model) renderedDiff() *diff.DiffResult {
+	visible := m.visibleDiff()
+	if visible == nil {
+		return nil
 	}
-	
-	return rows
+
+	return visible.LimitPerKind(m.maxPerKind)
 }
 
-func buildTableRowsForRemovedOnly(diffResult *diff.DiffResult) []table.Row {
-	var rows []table.Row
-	
-	// Add rows for removed resources only
-	for _, res := range diffResult.Removed {
-		rows = append(rows, table.Row{
-			"Removed",
-			res.Resource.GroupVersionKind,
-			res.Resource.Namespace,
-			res.Resource.Name,
-			res.Resource.ResourceVersion,
-			res.Resource.SpecHash,
-		})
+// filteredTableRows returns the table rows for the current filters, with the
+// first chunk built
+func (m Model) filteredTableRows() *tableRows {
+	visible := m.visibleDiff()
+	if visible == nil {
+		return nil
 	}
-	
-	return rows
+
+	switch m.resourceFilter {
+	case FilterAdded:
+		visible = visible.Filter(diff.FilterOptions{Operations: []diff.DiffType{diff.Added}})
+	case FilterRemoved:
+		visible = visible.Filter(diff.FilterOptions{Operations: []diff.DiffType{diff.Removed}})
+	case FilterModified:
+		visible = visible.Filter(diff.FilterOptions{Operations: []diff.DiffType{diff.Modified}})
+	}
+	return newTableRows(visible.LimitPerKind(m.maxPerKind))
 }
 
-func buildTableRowsForModifiedOnly(diffResult *diff.DiffResult) []table.Row {
-	var rows []table.Row
-	
-	// Add rows for modified resources only
-	for _, res := range diffResult.Modified {
-		rows = append(rows, table.Row{
-			"Modified",
-			res.Resource.GroupVersionKind,
-			res.Resource.Namespace,
-			res.Resource.Name,
-			fmt.Sprintf("%s → %s", res.OldResourceVersion, res.NewResourceVersion),
-			fmt.Sprintf("%s → %s", res.OldSpecHash, res.NewSpecHash),
-		})
+// New command to update table with filtered resources
+func (m Model) updateTableWithFilterCmd() tea.Cmd {
+	return func() tea.Msg {
+		if m.diffResult == nil {
+			return nil
+		}
+
+		return tableUpdatedMsg{rows: m.filteredTableRows()}
 	}
-	
-	return rows
+}
+
+type tableUpdatedMsg struct {
+	rows *tableRows
 }
 
 // View renders the current UI
 func (m Model) View() string {
 	var s strings.Builder
 
+	// Update never leaves a state without its snapshots, but rendering one would panic
+	if m.missingSnapshot() {
+		m.state = stateError
+		m.error = errNoSnapshot
+	}
+
 	// Header based on current state
 	switch m.state {
 	case stateReady:
 		s.WriteString("◆ Kubernetes Resource-Diff Utility\n\n")
 		s.WriteString("Press 'c' to capture baseline snapshot\n\n")
+		if m.statusMessage != "" {
+			s.WriteString(m.statusMessage + "\n\n")
+		}
 
 	case stateCapturingBaseline:
 		s.WriteString("◆ Kubernetes Resource-Diff Utility\n\n")
-		s.WriteString(fmt.Sprintf("%s Capturing baseline snapshot... Please wait\n\n", m.spinner.View()))
+		s.WriteString(fmt.Sprintf("%s Capturing baseline snapshot... %s\n\n", m.spinner.View(), m.progressText()))
+		s.WriteString(m.captureHint("Press esc to cancel the capture"))
 
 	case stateBaselineCaptured:
 		s.WriteString("◆ Kubernetes Resource-Diff Utility\n\n")
 		captureTime := m.baseline.Timestamp.Format(time.RFC3339)
 		s.WriteString(fmt.Sprintf("✅ Baseline captured at %s\n", captureTime))
+		if summary := m.baseline.ClusterSummary(); summary != "" {
+			s.WriteString(fmt.Sprintf("   Server: %s\n", summary))
+		}
 		
-		if m.namespace != "" {
-			s.WriteString(fmt.Sprintf("   Namespace: %s\n\n", m.namespace))
+		if len(m.captureOpts.Namespaces) > 0 {
+			s.WriteString(fmt.Sprintf("   Namespaces: %s\n\n", strings.Join(m.captureOpts.Namespaces, ", ")))
+		} else if m.captureOpts.Namespace != "" {
+			s.WriteString(fmt.Sprintf("   Namespace: %s\n\n", m.captureOpts.Namespace))
 		} else {
 			s.WriteString("   All namespaces\n\n")
 		}
@@ -887,7 +1529,8 @@ func (m Model) View() string {
 
 	case stateCapturingCurrent:
 		s.WriteString("◆ Kubernetes Resource-Diff Utility\n\n")
-		s.WriteString(fmt.Sprintf("%s Capturing current state... Please wait\n\n", m.spinner.View()))
+		s.WriteString(fmt.Sprintf("%s Capturing current state... %s\n\n", m.spinner.View(), m.progressText()))
+		s.WriteString(m.captureHint("Press esc to stop and diff what has been captured so far"))
 
 	case stateShowingDiff:
 		s.WriteString("◆ Kubernetes Resource-Diff Utility\n\n")
@@ -909,23 +1552,59 @@ func (m Model) View() string {
 		if m.clusterInfo != "" {
 			s.WriteString(fmt.Sprintf("Cluster: %s\n", m.clusterInfo))
 		}
+		if clusters := snapshot.DescribeClusters(m.baseline, m.current); clusters != "" {
+			s.WriteString(fmt.Sprintf("Server: %s\n", clusters))
+		}
 		
-		s.WriteString(fmt.Sprintf("Filter: %s\n\n", m.resourceFilter))
+		warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true)
+		if warning := partialWarning(m.baseline, m.current); warning != "" {
+			s.WriteString(warnStyle.Render("⚠ "+warning) + "\n")
+		}
+		for _, warning := range m.diffResult.Warnings {
+			s.WriteString(warnStyle.Render("⚠ "+warning) + "\n")
+		}
+		if immutable := len(m.visibleDiff().ImmutableFieldWarnings()); immutable > 0 {
+			immutableStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
+			s.WriteString(immutableStyle.Render(fmt.Sprintf("⚠ %d resources changed an immutable field; open them for details", immutable)) + "\n")
+		}
+		if rendered := m.renderedDiff(); len(rendered.Truncated) > 0 {
+			var capped []string
+			for _, kind := range rendered.TruncatedKinds() {
+				capped = append(capped, fmt.Sprintf("%s (%d more)", kind, rendered.Truncated[kind]))
+			}
+			s.WriteString(warnStyle.Render(fmt.Sprintf("⚠ Showing at most %d changes per kind: %s", m.maxPerKind, strings.Join(capped, ", "))) + "\n")
+		}
+
+		s.WriteString(fmt.Sprintf("Filter: %s\n", m.resourceFilter))
+		if !m.resultSelector.Empty() {
+			s.WriteString(fmt.Sprintf("Labels: %s\n", m.resultSelector))
+		}
+		if len(m.hiddenNamespaces) > 0 {
+			s.WriteString(fmt.Sprintf("Hidden namespaces: %s\n", strings.Join(m.hiddenNamespaces, ", ")))
+		}
+		if m.viewNamespace != "" {
+			s.WriteString(fmt.Sprintf("Namespace: %s (press 'f' to show all)\n", m.viewNamespace))
+		}
+		if m.showLegend {
+			s.WriteString(renderLegend() + "\n")
+		}
+		s.WriteString("\n")
 		
 		// Display resources based on output format
 		if m.outputFormat == "table" {
 			s.WriteString(m.table.View())
 			
 			// Add counts at the bottom
-			total := len(m.diffResult.Added) + len(m.diffResult.Removed) + len(m.diffResult.Modified)
+			visible := m.visibleDiff()
+			total := len(visible.Added) + len(visible.Removed) + len(visible.Modified)
 			countStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
 			s.WriteString("\n" + countStyle.Render(fmt.Sprintf(
 				"Total: %d resources | Added: %d | Removed: %d | Modified: %d",
-				total, len(m.diffResult.Added), len(m.diffResult.Removed), len(m.diffResult.Modified),
+				total, len(visible.Added), len(visible.Removed), len(visible.Modified),
 			)))
 			
 			// Hint for continuing to next snapshot
-			s.WriteString("\n" + countStyle.Render("Press 'c' to capture a new snapshot (will compare against current state)"))
+			s.WriteString("\n" + countStyle.Render("Press 'c' to capture a new snapshot (will compare against current state), 'r' to refresh against the same baseline"))
 		} else {
 			// YAML or JSON view
 			s.WriteString(m.viewport.View())
@@ -934,8 +1613,33 @@ func (m Model) View() string {
 		// Show hints based on current mode
 		hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
 		if m.outputFormat == "table" {
-			s.WriteString("\n" + hintStyle.Render("Use up/down arrows to select a resource and press Enter to view details"))
-			s.WriteString("\n" + hintStyle.Render("Press 0-3 to filter resources (0=all, 1=added, 2=removed, 3=modified)"))
+			s.WriteString("\n" + hintStyle.Render("Use up/down arrows to select a resource and press Enter to view details, n/N to jump to the next/previous one of its kind"))
+			s.WriteString("\n" + hintStyle.Render("Press 0-3 to filter resources (0=all, 1=added, 2=removed, 3=modified), 'l' to filter by labels"))
+		} else if m.outputFormat == "tree" {
+			s.WriteString("\n" + hintStyle.Render("Use up/down arrows to move, Enter to collapse/expand a group or view a resource's details"))
+		}
+		if m.keyMap.ShowExcluded.Enabled() {
+			s.WriteString("\n" + hintStyle.Render("Press 'v' to see which resource types weren't captured and why"))
+		}
+
+		if m.persistPrompt != "" {
+			s.WriteString("\n" + warnStyle.Render(fmt.Sprintf(
+				"Excluded %s for this session. Also save it to %s? (y/n)", m.persistPrompt, filter.IgnoreFileName)))
+		}
+
+		if m.editingSelector {
+			s.WriteString("\n" + m.selectorInput.View())
+			s.WriteString("\n" + hintStyle.Render("Press Enter to apply or Esc to cancel (empty selector shows everything)"))
+		}
+
+		// Show status message if present
+		if m.statusMessage != "" {
+			statusStyle := lipgloss.NewStyle().
+				Foreground(lipgloss.Color("86")).
+				Bold(true).
+				Padding(0, 1)
+
+			s.WriteString("\n" + statusStyle.Render(m.statusMessage))
 		}
 
 	case stateShowingResourceDetail:
@@ -957,15 +1661,52 @@ func (m Model) View() string {
 		
 		// Back hint
 		hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
-		s.WriteString("\n" + hintStyle.Render("Press 'b' or 'esc' or 'q' to go back to diff view, 'y' to copy YAML to clipboard"))
+		copyHint := "'y' to copy YAML to clipboard"
+		if !m.useClipboard {
+			copyHint = "'y' to save YAML to a temp file"
+		}
+		s.WriteString("\n" + hintStyle.Render("Press 'b' or 'esc' or 'q' to go back to diff view, "+copyHint+", 'e' to export the baseline and current YAML to files"))
+	case stateShowingSummary:
+		s.WriteString("◆ Change Summary\n\n")
+		s.WriteString(m.viewport.View())
+
+		hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+		s.WriteString("\n" + hintStyle.Render("Press 's', 'b' or 'esc' to go back to the diff view"))
+
+	case stateShowingExcluded:
+		s.WriteString("◆ Excluded Resource Types\n\n")
+		s.WriteString(m.viewport.View())
+
+		hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+		s.WriteString("\n" + hintStyle.Render("Press 'v', 'b' or 'esc' to go back to the diff view"))
+
 	case stateError:
 		s.WriteString("⚠️ Error\n\n")
 		s.WriteString(fmt.Sprintf("%v\n\n", m.error))
-		s.WriteString("Press 'q' to quit or 'b' to go back\n")
+		if m.failedCapture != stateReady {
+			s.WriteString("Press 'r' to retry the capture, 'q' to quit or 'b' to go back\n")
+		} else {
+			s.WriteString("Press 'q' to quit or 'b' to go back\n")
+		}
+	}
+
+	if m.limitPrompt != nil {
+		warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true)
+		s.WriteString("\n" + warnStyle.Render(fmt.Sprintf(
+			"%d objects captured so far, over the limit of %d — keep capturing? (y/n)",
+			m.limitPrompt.total, m.captureOpts.MaxResources)) + "\n")
+	}
+
+	if m.confirmQuit {
+		warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
+		s.WriteString("\n" + warnStyle.Render("Capture in progress — really quit? (y/n)") + "\n")
 	}
 
 	// Footer
 	var footer strings.Builder
+	if m.state == stateShowingDiff && m.outputFormat == "table" && m.tableRows != nil && m.tableRows.total() > 0 {
+		footer.WriteString(fmt.Sprintf("Row %d of %d · ", m.table.Cursor()+1, m.tableRows.total()))
+	}
 	if m.showHelp {
 		footer.WriteString(m.help.View(m.keyMap))
 	} else {
@@ -975,6 +1716,54 @@ func (m Model) View() string {
 	return lipgloss.JoinVertical(lipgloss.Left, s.String(), footer.String())
 }
 
+// partialWarning describes why the compared snapshots may be incomplete, if they are
+func partialWarning(baseline, current *snapshot.Snapshot) string {
+	if !baseline.Partial && !current.Partial {
+		return ""
+	}
+
+	failed := len(baseline.FailedTypes) + len(current.FailedTypes)
+	for _, snap := range []*snapshot.Snapshot{baseline, current} {
+		for _, types := range snap.NamespaceFailedTypes {
+			failed += len(types)
+		}
+	}
+	if failed > 0 {
+		return fmt.Sprintf("%d resource type(s) failed to list and were left out of the diff; results may be incomplete", failed)
+	}
+
+	unlisted := len(baseline.UnlistedTypes) + len(current.UnlistedTypes)
+	if unlisted > 0 {
+		return fmt.Sprintf("Capture was cancelled: %d resource type(s) weren't listed and were left out of the diff; results may be incomplete", unlisted)
+	}
+
+	return "Capture was cancelled: snapshot is partial and results may be incomplete"
+}
+
+// progressText describes how far the running capture has got, e.g.
+// "42% (21 of 50 resource types, about 30s left)"
+func (m Model) progressText() string {
+	p := m.captureProgress
+	if p == nil || p.Total == 0 {
+		return "Please wait"
+	}
+
+	text := fmt.Sprintf("%d%% (%d of %d resource types", p.Percent(), p.Done, p.Total)
+	if remaining := p.Remaining(); remaining > 0 {
+		text += fmt.Sprintf(", about %s left", remaining.Round(time.Second))
+	}
+	return text + ")"
+}
+
+// captureHint renders the hint shown while a capture is running
+func (m Model) captureHint(hint string) string {
+	if m.statusMessage != "" {
+		hint = m.statusMessage
+	}
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+	return hintStyle.Render(hint) + "\n\n"
+}
+
 // Helper to create styled table
 func tableStyles() table.Styles {
 	s := table.DefaultStyles()
@@ -1011,8 +1800,23 @@ type currentStateCapturedMsg struct {
 	err      error
 }
 
+// resourceLimitMsg asks whether a capture may continue past --max-resources. The
+// capture blocks until answer receives a reply; wait then resumes listening to it.
+type resourceLimitMsg struct {
+	total  int
+	answer chan<- bool
+	wait   tea.Cmd
+}
+
+// captureProgressMsg reports the progress of a capture; wait resumes listening to it
+type captureProgressMsg struct {
+	progress snapshot.CaptureProgress
+	wait     tea.Cmd
+}
+
 type diffOutputUpdatedMsg struct {
 	output string
+	stream *outputStream // Rest of the yaml/json output, still to be highlighted
 }
 
 type clearStatusMessageMsg struct{}
@@ -1024,18 +1828,78 @@ func hideStatusMessageCmd(seconds int) tea.Cmd {
 	})
 }
 
+// newCaptureContext creates the context for a new capture, remembering how to cancel it
+func (m *Model) newCaptureContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelCapture = cancel
+	return ctx
+}
+
 // Commands
-func (m Model) captureBaselineCmd() tea.Cmd {
-	return func() tea.Msg {
-		snapshot, err := snapshot.CaptureSnapshot(m.namespace, m.ignorePattern, m.kubeconfigPath)
+func (m Model) captureBaselineCmd(ctx context.Context) tea.Cmd {
+	return m.captureCmd(ctx, func(snapshot *snapshot.Snapshot, err error) tea.Msg {
 		return baselineCapturedMsg{snapshot: snapshot, err: err}
-	}
+	})
 }
 
-func (m Model) captureCurrentStateCmd() tea.Cmd {
-	return func() tea.Msg {
-		snapshot, err := snapshot.CaptureSnapshot(m.namespace, m.ignorePattern, m.kubeconfigPath)
+func (m Model) captureCurrentStateCmd(ctx context.Context) tea.Cmd {
+	return m.captureCmd(ctx, func(snapshot *snapshot.Snapshot, err error) tea.Msg {
 		return currentStateCapturedMsg{snapshot: snapshot, err: err}
+	})
+}
+
+// captureCmd runs a capture in the background and reports its result through done,
+// and its progress through captureProgressMsgs. If the capture goes past the
+// resource limit it pauses and a resourceLimitMsg asks the user whether to carry on.
+func (m Model) captureCmd(ctx context.Context, done func(*snapshot.Snapshot, error) tea.Msg) tea.Cmd {
+	prompts := make(chan resourceLimitMsg)
+	progress := make(chan snapshot.CaptureProgress, 1)
+	results := make(chan tea.Msg, 1)
+
+	var wait tea.Cmd
+	wait = func() tea.Msg {
+		select {
+		case prompt := <-prompts:
+			prompt.wait = wait
+			return prompt
+		case p := <-progress:
+			return captureProgressMsg{progress: p, wait: wait}
+		case msg := <-results:
+			return msg
+		}
+	}
+
+	opts := m.captureOpts
+	opts.Progress = func(p snapshot.CaptureProgress) {
+		// Only the latest progress matters, so replace any that wasn't shown yet
+		select {
+		case <-progress:
+		default:
+		}
+		progress <- p
+	}
+	opts.ConfirmLimit = func(total int) bool {
+		answer := make(chan bool, 1)
+		select {
+		case prompts <- resourceLimitMsg{total: total, answer: answer}:
+		case <-ctx.Done():
+			return false
+		}
+
+		select {
+		case ok := <-answer:
+			return ok
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	return func() tea.Msg {
+		go func() {
+			snap, err := m.capture(ctx, opts)
+			results <- done(snap, err)
+		}()
+		return wait()
 	}
 }
 
@@ -1043,14 +1907,20 @@ func (m Model) updateDiffOutputCmd() tea.Cmd {
 	return func() tea.Msg {
 		var output strings.Builder
 		
+		visible := m.renderedDiff()
+
 		switch m.outputFormat {
-		case "json":
-			diff.OutputJSON(m.diffResult, &output)
-		case "yaml":
-			diff.OutputYAML(m.diffResult, &output)
+		case "json", "yaml":
+			// Huge diffs are rendered a chunk at a time, starting with this one
+			stream := newOutputStream(visible, m.outputFormat)
+			first := stream.next()
+			if stream.done() {
+				stream = nil
+			}
+			return diffOutputUpdatedMsg{output: first, stream: stream}
 		default:
 			// Table output is handled by the table component
-			if m.diffResult.IsEmpty() {
+			if visible.IsEmpty() {
 				output.WriteString("No differences detected")
 			}
 		}
@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// captureBaseline presses 'c' on a new model whose captures return snap and err,
+// and hands the finished capture back to the model
+func captureBaseline(t *testing.T, snap *snapshot.Snapshot, err error) Model {
+	t.Helper()
+	m := New(Options{NoClipboard: true})
+	m.capture = func(context.Context, snapshot.CaptureOptions) (*snapshot.Snapshot, error) {
+		return snap, err
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m = updated.(Model)
+	if m.state != stateCapturingBaseline {
+		t.Fatalf("state after 'c' = %v, want stateCapturingBaseline", m.state)
+	}
+
+	msg := m.captureBaselineCmd(context.Background())()
+	if _, ok := msg.(baselineCapturedMsg); !ok {
+		t.Fatalf("capture finished with %T, want baselineCapturedMsg", msg)
+	}
+	updated, _ = m.Update(msg)
+	return updated.(Model)
+}
+
+func TestFailedBaselineCapture(t *testing.T) {
+	m := captureBaseline(t, nil, errors.New(`deployments.apps is forbidden: User "dev" cannot list resource "deployments"`))
+
+	if m.state != stateError {
+		t.Fatalf("state = %v, want stateError", m.state)
+	}
+	if m.baseline != nil {
+		t.Error("a failed capture kept a baseline")
+	}
+	if m.failedCapture != stateCapturingBaseline {
+		t.Errorf("failedCapture = %v, want stateCapturingBaseline so it can be retried", m.failedCapture)
+	}
+	if m.error == nil || !strings.Contains(m.error.Error(), "baseline capture failed") || !strings.Contains(m.error.Error(), "forbidden") {
+		t.Errorf("error = %v, want the capture failure", m.error)
+	}
+}
+
+func TestBaselineCaptureWithoutSnapshot(t *testing.T) {
+	m := captureBaseline(t, nil, nil)
+
+	if m.state != stateError || m.baseline != nil {
+		t.Fatalf("state = %v with baseline %v, want stateError without one", m.state, m.baseline)
+	}
+	if !errors.Is(m.error, errNoSnapshot) {
+		t.Errorf("error = %v, want errNoSnapshot", m.error)
+	}
+}
+
+func TestBaselineCapture(t *testing.T) {
+	snap := &snapshot.Snapshot{Resources: map[string]snapshot.ResourceInfo{}}
+	m := captureBaseline(t, snap, nil)
+
+	if m.state != stateBaselineCaptured || m.baseline != snap {
+		t.Errorf("state = %v with baseline %p, want stateBaselineCaptured with %p", m.state, m.baseline, snap)
+	}
+}
+
+func TestResourceDetailOfPathologicalManifests(t *testing.T) {
+	manifests := []string{
+		"",
+		"spec: [unterminated\n",
+		"rules: 5\n",
+		"rules:\n- apiGroups: 5\n  verbs: {a: b}\n",
+		"data: [1, 2]\nbinaryData: hello\n",
+		"a: &a [*a]\n",
+		"\x00\xff\xfe\r\n\r\n\t",
+		strings.Repeat("x", 1<<16),
+		strings.Repeat("key: value\n", 5000),
+		strings.Repeat("  ", 500) + "deep: true\n",
+	}
+
+	m := New(Options{NoClipboard: true})
+	for _, gvk := range []string{"rbac.authorization.k8s.io/v1/Role", "v1/Secret", "apps/v1/Deployment"} {
+		for i, oldManifest := range manifests {
+			newManifest := manifests[(i+1)%len(manifests)]
+			resource := snapshot.ResourceInfo{GroupVersionKind: gvk, Namespace: "payments", Name: "pathological"}
+			baseline, current := resource, resource
+			baseline.Manifest, current.Manifest = oldManifest, newManifest
+
+			for _, res := range []diff.ResourceDiff{
+				{Type: diff.Modified, Resource: resource, BaselineResource: &baseline, CurrentResource: &current},
+				{Type: diff.Added, Resource: resource, CurrentResource: &current},
+				{Type: diff.Removed, Resource: resource, BaselineResource: &baseline},
+			} {
+				res := res
+				m.selectedResource = &res
+				msg, ok := m.loadResourceDetailCmd()().(resourceDetailLoadedMsg)
+				if !ok {
+					t.Fatalf("%s %s of manifest %d: no details loaded", res.Type, gvk, i)
+				}
+				if !strings.Contains(msg.output, "Name: pathological") || !strings.Contains(msg.output, "---\n") {
+					t.Errorf("%s %s of manifest %d: details lack the resource header:\n%.200s", res.Type, gvk, i, msg.output)
+				}
+			}
+		}
+	}
+}
+
+func TestExportManifestsRedactsSecrets(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// Captured with --keep-secret-values, so the manifests hold the values
+	secret := func(password string) string {
+		return "apiVersion: v1\nkind: Secret\nmetadata:\n  name: db\n  namespace: payments\ndata:\n  password: " + password + "\n  user: YWRtaW4=\n"
+	}
+	paths, err := exportManifests("payments/db", secret("aHVudGVyMg=="), secret("c3dvcmRmaXNo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("exported %v, want the baseline and current manifests", paths)
+	}
+
+	var users []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != 0600 {
+			t.Errorf("%s written with mode %o, want 600", path, mode)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, value := range []string{"aHVudGVyMg==", "c3dvcmRmaXNo", "YWRtaW4="} {
+			if strings.Contains(string(content), value) {
+				t.Errorf("%s holds the Secret value %s:\n%s", path, value, content)
+			}
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			if strings.Contains(line, "user:") {
+				users = append(users, line)
+			}
+		}
+	}
+	if len(users) != 2 || users[0] != users[1] {
+		t.Errorf("the unchanged value has different digests in the two files: %q", users)
+	}
+}
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// clipboardAvailable reports whether copying to the system clipboard is likely to
+// work. Over SSH or without a display server the clipboard tools can't be reached.
+func clipboardAvailable() bool {
+	if clipboard.Unsupported {
+		return false
+	}
+
+	hasDisplay := os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+
+	// Remote sessions only have a clipboard when X11 forwarding is enabled
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return hasDisplay
+	}
+
+	if runtime.GOOS == "linux" || runtime.GOOS == "freebsd" {
+		return hasDisplay
+	}
+
+	return true
+}
+
+// writeManifestToTempFile saves a manifest to a temporary file and returns its path
+func writeManifestToTempFile(name, manifest string) (string, error) {
+	f, err := os.CreateTemp("", fmt.Sprintf("k8s-rdiff-%s-*.yaml", safeFileName(name)))
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(manifest); err != nil {
+		return "", fmt.Errorf("failed to write temp file: %v", err)
+	}
+
+	return f.Name(), nil
+}
+
+// exportManifests writes the baseline and current manifests of a resource to
+// <name>.baseline.yaml and <name>.current.yaml in the working directory, so they
+// can be compared with another diff tool. A missing manifest (e.g. the baseline
+// of an added resource) is skipped. Secret values are redacted, with the same
+// digest for the same value in both files, and the files are only readable by
+// their owner. It returns the paths written.
+func exportManifests(name, baseline, current string) ([]string, error) {
+	var paths []string
+	for _, manifest := range []struct{ suffix, content string }{
+		{"baseline", baseline},
+		{"current", current},
+	} {
+		if manifest.content == "" {
+			continue
+		}
+
+		path := fmt.Sprintf("%s.%s.yaml", safeFileName(name), manifest.suffix)
+		content, err := snapshot.RedactSecretManifest(manifest.content)
+		if err != nil {
+			return paths, fmt.Errorf("failed to redact %s: %v", path, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			return paths, fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		// WriteFile keeps the mode of a file exported before
+		if err := os.Chmod(path, 0600); err != nil {
+			return paths, fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// safeFileName keeps a resource name readable but safe to use in a file name
+func safeFileName(name string) string {
+	return strings.NewReplacer("/", "_", ":", "_", " ", "_").Replace(name)
+}
@@ -0,0 +1,63 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/winson-sou/k8s-rdiff/internal/snapshot"
+)
+
+// renderExcluded renders the resource types a capture left out and why: those
+// excluded by the filters or that can't be listed, and those that failed to list
+func renderExcluded(snap *snapshot.Snapshot) string {
+	if snap == nil {
+		return "Nothing captured yet\n"
+	}
+
+	reasons := map[string]string{}
+	for _, excluded := range snap.ExcludedTypes {
+		reasons[excluded.ResourceType] = excluded.Reason
+	}
+	for _, failed := range snap.FailedTypes {
+		reasons[failed] = "failed to list"
+	}
+	for _, unlisted := range snap.UnlistedTypes {
+		reasons[unlisted] = "not listed, capture stopped early"
+	}
+	failedIn := map[string][]string{}
+	for namespace, types := range snap.NamespaceFailedTypes {
+		for _, failed := range types {
+			failedIn[failed] = append(failedIn[failed], namespace)
+		}
+	}
+	for failed, namespaces := range failedIn {
+		if _, known := reasons[failed]; !known {
+			sort.Strings(namespaces)
+			reasons[failed] = "failed to list in " + strings.Join(namespaces, ", ")
+		}
+	}
+	if len(reasons) == 0 {
+		return "No resource types were excluded\n"
+	}
+
+	types := make([]string, 0, len(reasons))
+	width := 0
+	for resourceType := range reasons {
+		types = append(types, resourceType)
+		if len(resourceType) > width {
+			width = len(resourceType)
+		}
+	}
+	sort.Strings(types)
+
+	var s strings.Builder
+	for _, resourceType := range types {
+		s.WriteString(fmt.Sprintf("%-*s  %s\n", width, resourceType, reasons[resourceType]))
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	s.WriteString("\n" + labelStyle.Render(fmt.Sprintf("%d resource types not captured", len(types))) + "\n")
+	return s.String()
+}
@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+)
+
+var (
+	keyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("81"))
+	stringStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+	numberStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+	literalStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("208"))
+
+	// Top-level diff sections use the same colors as the legend
+	sectionStyles = map[string]lipgloss.Style{
+		"added":    operationStyles[diff.Added],
+		"removed":  operationStyles[diff.Removed],
+		"modified": operationStyles[diff.Modified],
+		"warnings": lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true),
+	}
+
+	yamlLineRe   = regexp.MustCompile(`^(\s*(?:- )?)([^\s:"'][^:]*|"[^"]*"|'[^']*'):(?:\s(.*))?$`)
+	jsonLineRe   = regexp.MustCompile(`^(\s*)("(?:[^"\\]|\\.)*"):\s(.*?)(,?)$`)
+	numberRe     = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][-+]?\d+)?$`)
+	yamlItemRe   = regexp.MustCompile(`^(\s*- )(.*)$`)
+	jsonScalarRe = regexp.MustCompile(`^(\s*)(.*?)(,?)$`)
+)
+
+// highlightStructured colors keys and values of YAML or JSON output line by line
+func highlightStructured(output, format string) string {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		switch format {
+		case "json":
+			lines[i] = highlightJSONLine(line)
+		case "yaml":
+			lines[i] = highlightYAMLLine(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func highlightYAMLLine(line string) string {
+	if m := yamlLineRe.FindStringSubmatch(line); m != nil {
+		indent, key, value := m[1], m[2], m[3]
+
+		style := keyStyle
+		if section, ok := sectionStyles[key]; ok && indent == "" {
+			style = section
+		}
+
+		if value == "" {
+			return indent + style.Render(key) + ":"
+		}
+		return indent + style.Render(key) + ": " + highlightValue(value)
+	}
+
+	if m := yamlItemRe.FindStringSubmatch(line); m != nil {
+		return m[1] + highlightValue(m[2])
+	}
+
+	return line
+}
+
+func highlightJSONLine(line string) string {
+	if m := jsonLineRe.FindStringSubmatch(line); m != nil {
+		indent, key, value, comma := m[1], m[2], m[3], m[4]
+
+		style := keyStyle
+		if section, ok := sectionStyles[strings.Trim(key, `"`)]; ok && len(indent) <= 2 {
+			style = section
+		}
+
+		return indent + style.Render(key) + ": " + highlightValue(value) + comma
+	}
+
+	// Array elements
+	if m := jsonScalarRe.FindStringSubmatch(line); m != nil && m[2] != "" {
+		return m[1] + highlightValue(m[2]) + m[3]
+	}
+
+	return line
+}
+
+// highlightValue colors a scalar value by its type, leaving brackets untouched
+func highlightValue(value string) string {
+	switch {
+	case value == "{" || value == "[" || value == "{}" || value == "[]" || value == "}" || value == "]":
+		return value
+	case value == "true" || value == "false" || value == "null" || value == "~":
+		return literalStyle.Render(value)
+	case numberRe.MatchString(value):
+		return numberStyle.Render(value)
+	default:
+		return stringStyle.Render(value)
+	}
+}
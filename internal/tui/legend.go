@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+)
+
+// operationStyles color each kind of change, matching the CLI table output
+var operationStyles = map[diff.DiffType]lipgloss.Style{
+	diff.Added:    lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true),
+	diff.Removed:  lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true),
+	diff.Modified: lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true),
+}
+
+// operationSymbols are the markers used for each kind of change
+var operationSymbols = map[diff.DiffType]string{
+	diff.Added:    "+",
+	diff.Removed:  "-",
+	diff.Modified: "~",
+}
+
+// renderLegend renders the color key shown above the diff
+func renderLegend() string {
+	entries := []string{}
+	for _, op := range []diff.DiffType{diff.Added, diff.Removed, diff.Modified} {
+		entries = append(entries, operationStyles[op].Render(operationSymbols[op]+" "+string(op)))
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	return labelStyle.Render("Legend: ") + strings.Join(entries, "  ")
+}
@@ -0,0 +1,190 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+)
+
+// tableRowChunk is how many table rows are built at a time. More are built as the
+// cursor gets near the last one, so huge diffs don't block the UI up front.
+const tableRowChunk = 500
+
+// tableRows builds the table rows of a diff on demand, in display order: added,
+// removed and modified resources, then an "… and N more" row per capped kind
+type tableRows struct {
+	source    *diff.DiffResult
+	truncated []string // Kinds capped by --max-per-kind
+	rows      []table.Row
+}
+
+// newTableRows returns the rows of d with the first chunk built
+func newTableRows(d *diff.DiffResult) *tableRows {
+	t := &tableRows{source: d, truncated: d.TruncatedKinds()}
+	t.buildUpTo(tableRowChunk)
+	return t
+}
+
+// total returns the number of rows once all are built
+func (t *tableRows) total() int {
+	return len(t.source.Added) + len(t.source.Removed) + len(t.source.Modified) + len(t.truncated)
+}
+
+// complete reports whether every row has been built
+func (t *tableRows) complete() bool {
+	return len(t.rows) == t.total()
+}
+
+// buildUpTo builds the rows up to (not including) index n
+func (t *tableRows) buildUpTo(n int) {
+	if total := t.total(); n > total {
+		n = total
+	}
+	for i := len(t.rows); i < n; i++ {
+		t.rows = append(t.rows, t.row(i))
+	}
+}
+
+// row builds the i-th row
+func (t *tableRows) row(i int) table.Row {
+	d := t.source
+	switch {
+	case i < len(d.Added):
+		return resourceRow(d.Added[i])
+	case i < len(d.Added)+len(d.Removed):
+		return resourceRow(d.Removed[i-len(d.Added)])
+	case i < len(d.Added)+len(d.Removed)+len(d.Modified):
+		return resourceRow(d.Modified[i-len(d.Added)-len(d.Removed)])
+	}
+
+	// The rows don't match a resource, so they have no details
+	kind := t.truncated[i-len(d.Added)-len(d.Removed)-len(d.Modified)]
+	return table.Row{"…", kind, "", fmt.Sprintf("and %d more", d.Truncated[kind]), "", "", ""}
+}
+
+// resourceRow builds the table row of a changed resource
+func resourceRow(res diff.ResourceDiff) table.Row {
+	version, hash := res.Resource.ResourceVersion, res.Resource.SpecHash
+	if res.Type == diff.Modified {
+		version = fmt.Sprintf("%s → %s", res.OldResourceVersion, res.NewResourceVersion)
+		hash = fmt.Sprintf("%s → %s", res.OldSpecHash, res.NewSpecHash)
+	}
+
+	return table.Row{
+		string(res.Type),
+		res.Resource.GroupVersionKind,
+		res.Resource.Namespace,
+		res.Resource.Name,
+		version,
+		hash,
+		res.Severity.String(),
+	}
+}
+
+// setTableRows shows the rows in the table, keeping the cursor in range
+func (m *Model) setTableRows(rows *tableRows) {
+	m.tableRows = rows
+	if rows == nil {
+		m.table.SetRows(nil)
+		return
+	}
+	m.table.SetRows(rows.rows)
+}
+
+// extendTableRows builds more rows once the cursor is within a chunk of the last
+// built one, or all of them if all is set (e.g. to search them)
+func (m *Model) extendTableRows(all bool) {
+	rows := m.tableRows
+	if rows == nil || rows.complete() {
+		return
+	}
+
+	switch {
+	case all:
+		rows.buildUpTo(rows.total())
+	case m.table.Cursor() >= len(rows.rows)-tableRowChunk/2:
+		rows.buildUpTo(len(rows.rows) + tableRowChunk)
+	default:
+		return
+	}
+	m.table.SetRows(rows.rows)
+}
+
+// outputChunkLines is about how many lines of yaml/json output are rendered before
+// they're first shown. Each following chunk is twice as large, so the output is
+// copied into the viewport only a few times however long it is.
+const outputChunkLines = 2000
+
+// outputStream is yaml/json diff output still being rendered into the viewport
+type outputStream struct {
+	format string
+	pieces []func() string // Output not rendered yet, in order
+	size   int             // Lines to render in the next chunk
+}
+
+// newOutputStream returns a stream of the diff in the given format (yaml or json)
+func newOutputStream(visible *diff.DiffResult, format string) *outputStream {
+	stream := &outputStream{format: format, size: outputChunkLines}
+	if format == "yaml" {
+		// Marshalling YAML is the slow part, so it's done a resource at a time
+		stream.pieces = diff.YAMLPieces(visible)
+		return stream
+	}
+
+	var output strings.Builder
+	diff.OutputJSON(visible, &output)
+	for text := output.String(); text != ""; {
+		block := text[:blockEnd(text, outputChunkLines)]
+		stream.pieces = append(stream.pieces, func() string { return block })
+		text = text[len(block):]
+	}
+	return stream
+}
+
+// blockEnd returns the end of the first n lines of text, including their newlines
+func blockEnd(text string, n int) int {
+	end := 0
+	for i := 0; i < n; i++ {
+		newline := strings.IndexByte(text[end:], '\n')
+		if newline < 0 {
+			return len(text)
+		}
+		end += newline + 1
+	}
+	return end
+}
+
+// done reports whether the whole output has been rendered
+func (s *outputStream) done() bool {
+	return len(s.pieces) == 0
+}
+
+// next renders and highlights the next chunk of output, doubling the chunk size
+func (s *outputStream) next() string {
+	var chunk strings.Builder
+	lines := 0
+	for len(s.pieces) > 0 && lines < s.size {
+		piece := s.pieces[0]()
+		s.pieces = s.pieces[1:]
+		chunk.WriteString(piece)
+		lines += strings.Count(piece, "\n")
+	}
+	s.size *= 2
+	return highlightStructured(chunk.String(), s.format)
+}
+
+// outputChunkMsg carries the next rendered chunk of a stream
+type outputChunkMsg struct {
+	stream *outputStream
+	chunk  string
+}
+
+// streamOutputCmd renders the next chunk of the stream in the background
+func streamOutputCmd(stream *outputStream) tea.Cmd {
+	return func() tea.Msg {
+		return outputChunkMsg{stream: stream, chunk: stream.next()}
+	}
+}
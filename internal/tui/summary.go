@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+)
+
+// operations lists the kinds of change in display order
+var operations = []diff.DiffType{diff.Added, diff.Removed, diff.Modified}
+
+// renderSummary renders the triage view: change counts per operation followed by
+// a per-kind breakdown, e.g. "apps/v1/Deployment  2 modified"
+func renderSummary(result *diff.DiffResult) string {
+	var s strings.Builder
+
+	totals := []string{}
+	for i, n := range []int{len(result.Added), len(result.Removed), len(result.Modified)} {
+		op := operations[i]
+		totals = append(totals, operationStyles[op].Render(fmt.Sprintf("%s %d %s", operationSymbols[op], n, op)))
+	}
+	s.WriteString(strings.Join(totals, "  ") + "\n\n")
+
+	kinds := result.SummaryByKind()
+	if len(kinds) == 0 {
+		s.WriteString("No differences detected\n")
+		return s.String()
+	}
+
+	width := 0
+	for _, kind := range kinds {
+		if len(kind.Kind) > width {
+			width = len(kind.Kind)
+		}
+	}
+
+	for _, kind := range kinds {
+		parts := []string{}
+		for i, n := range []int{kind.Added, kind.Removed, kind.Modified} {
+			if n > 0 {
+				op := operations[i]
+				parts = append(parts, operationStyles[op].Render(fmt.Sprintf("%d %s", n, strings.ToLower(string(op)))))
+			}
+		}
+		s.WriteString(fmt.Sprintf("%-*s  %s\n", width, kind.Kind, strings.Join(parts, ", ")))
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	s.WriteString("\n" + labelStyle.Render(fmt.Sprintf("%d kinds changed", len(kinds))) + "\n")
+	return s.String()
+}
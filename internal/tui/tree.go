@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/winson-sou/k8s-rdiff/internal/diff"
+)
+
+// treeView is the collapsible namespace → kind → name view of a diff
+type treeView struct {
+	namespaces []diff.TreeNamespace
+	collapsed  map[string]bool // Collapsed namespace and kind nodes, by treeLine.key
+	cursor     int             // Index of the selected visible line
+}
+
+// treeLine is one visible line of the tree
+type treeLine struct {
+	key      string             // Identifies namespace and kind nodes; empty for resources
+	depth    int                // 0 for namespaces, 1 for kinds, 2 for resources
+	text     string             // Rendered text, without indentation
+	resource *diff.ResourceDiff // The resource on resource lines
+}
+
+// newTreeView builds the tree of a diff, keeping the collapsed nodes and the
+// cursor position of the previous tree
+func newTreeView(result *diff.DiffResult, previous treeView) treeView {
+	collapsed := previous.collapsed
+	if collapsed == nil {
+		collapsed = map[string]bool{}
+	}
+
+	t := treeView{
+		namespaces: result.Tree(),
+		collapsed:  collapsed,
+		cursor:     previous.cursor,
+	}
+	t.move(0)
+	return t
+}
+
+// lines returns the lines of the tree that aren't hidden by a collapsed node
+func (t treeView) lines() []treeLine {
+	var lines []treeLine
+	for _, namespace := range t.namespaces {
+		nsKey := "ns/" + namespace.Label()
+		lines = append(lines, treeLine{
+			key:  nsKey,
+			text: fmt.Sprintf("%s %s (%d)", t.expander(nsKey), namespace.Label(), namespace.Count()),
+		})
+		if t.collapsed[nsKey] {
+			continue
+		}
+
+		for _, kind := range namespace.Kinds {
+			kindKey := nsKey + "/" + kind.Kind
+			lines = append(lines, treeLine{
+				key:   kindKey,
+				depth: 1,
+				text:  fmt.Sprintf("%s %s (%d)", t.expander(kindKey), kind.Kind, len(kind.Resources)),
+			})
+			if t.collapsed[kindKey] {
+				continue
+			}
+
+			for i := range kind.Resources {
+				res := &kind.Resources[i]
+				lines = append(lines, treeLine{
+					depth:    2,
+					text:     operationStyles[res.Type].Render(diff.TreeMarker(res.Type)) + " " + res.Resource.Name,
+					resource: res,
+				})
+			}
+		}
+	}
+	return lines
+}
+
+// expander returns the marker showing whether a node is collapsed
+func (t treeView) expander(key string) string {
+	if t.collapsed[key] {
+		return "▸"
+	}
+	return "▾"
+}
+
+// move moves the cursor by delta lines, staying within the tree
+func (t *treeView) move(delta int) {
+	t.cursor += delta
+	if last := len(t.lines()) - 1; t.cursor > last {
+		t.cursor = last
+	}
+	if t.cursor < 0 {
+		t.cursor = 0
+	}
+}
+
+// selected returns the line under the cursor, or nil for an empty tree
+func (t treeView) selected() *treeLine {
+	lines := t.lines()
+	if t.cursor >= len(lines) {
+		return nil
+	}
+	return &lines[t.cursor]
+}
+
+// toggle collapses or expands the namespace or kind under the cursor
+func (t *treeView) toggle() {
+	line := t.selected()
+	if line == nil || line.key == "" {
+		return
+	}
+	t.collapsed[line.key] = !t.collapsed[line.key]
+}
+
+// render renders the visible lines, marking the one under the cursor
+func (t treeView) render() string {
+	lines := t.lines()
+	if len(lines) == 0 {
+		return "No differences detected"
+	}
+
+	cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true)
+	rendered := make([]string, len(lines))
+	for i, line := range lines {
+		prefix := "  "
+		if i == t.cursor {
+			prefix = cursorStyle.Render("> ")
+		}
+		rendered[i] = prefix + strings.Repeat("  ", line.depth) + line.text
+	}
+	return strings.Join(rendered, "\n")
+}
+
+// syncTree shows the tree in the viewport, scrolled so the cursor is visible
+func (m *Model) syncTree() {
+	m.diffOutput = m.tree.render()
+	m.viewport.SetContent(m.diffOutput)
+
+	if m.tree.cursor < m.viewport.YOffset {
+		m.viewport.SetYOffset(m.tree.cursor)
+	} else if bottom := m.viewport.YOffset + m.viewport.Height - 1; m.tree.cursor > bottom {
+		m.viewport.SetYOffset(m.tree.cursor - m.viewport.Height + 1)
+	}
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
@@ -10,21 +11,29 @@ import (
 // Dialog handles user interaction for the diff process
 type Dialog struct {
 	reader *bufio.Reader
+	out    io.Writer
 }
 
 // NewDialog creates a new dialog instance
 func NewDialog() *Dialog {
 	return &Dialog{
 		reader: bufio.NewReader(os.Stdin),
+		out:    os.Stdout,
 	}
 }
 
+// WithOutput sets where prompts are written (stdout by default)
+func (d *Dialog) WithOutput(w io.Writer) *Dialog {
+	d.out = w
+	return d
+}
+
 // WaitForUserAction prompts the user to execute their commands and continue
 func (d *Dialog) WaitForUserAction() error {
-	fmt.Println("Execute your command(s) and type 'continue' when done.")
+	fmt.Fprintln(d.out, "Execute your command(s) and type 'continue' when done.")
 	
 	for {
-		fmt.Print("> ")
+		fmt.Fprint(d.out, "> ")
 		input, err := d.reader.ReadString('\n')
 		if err != nil {
 			return fmt.Errorf("error reading input: %v", err)
@@ -36,7 +45,7 @@ func (d *Dialog) WaitForUserAction() error {
 		} else if strings.ToLower(input) == "exit" || strings.ToLower(input) == "quit" {
 			return fmt.Errorf("diff process canceled by user")
 		} else if input != "" {
-			fmt.Println("Type 'continue' to proceed with diff, or 'exit' to cancel.")
+			fmt.Fprintln(d.out, "Type 'continue' to proceed with diff, or 'exit' to cancel.")
 		}
 	}
 	
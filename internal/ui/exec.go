@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// RunCommand runs command through the shell in place of waiting for the user,
+// streaming its stdout and stderr to the dialog's output. It returns an error if
+// the command can't be started or exits non-zero.
+func (d *Dialog) RunCommand(command string) error {
+	fmt.Fprintf(d.out, "Running: %s\n", command)
+
+	cmd := exec.Command("sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = d.out
+	cmd.Stderr = d.out
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("command %q failed: %v", command, err)
+	}
+	return nil
+}